@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"time"
 	runner "github.com/alanctgardner/ampl-go/runner"
 	model "github.com/alanctgardner/ampl-go/model"
@@ -55,7 +56,12 @@ func main() {
 	amplRunner.Stop()
 
 	/* Load the .nl file */
-	p := model.ProblemFromFile("diet1.nl")
+	p, err := model.LoadProblem("diet1.nl")
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return
+	}
+	defer p.Close()
 
 	/* Print all the variables in the problem */
 	fmt.Printf("\nVariables\n---\n")
@@ -75,16 +81,18 @@ func main() {
 		fmt.Printf("Objective %v - %s\n", o.Index, o)
 	}
 
-	/* Get random values within the bounds of every variable */
-	fmt.Printf("\nRandom variable values:\n---\n")
-	vars := make([]float64, len(p.Variables())) 
+	/* Start from the initial values in the model, and random values within the bounds for the other variables */
+	fmt.Printf("\nStarting variable values:\n---\n")
+	vars, given := p.InitialPrimal()
 	rand.Seed(time.Now().Unix())
 	for i, v := range p.Variables() {
-		vars[i] = (rand.Float64() * (v.UpperBound() - v.LowerBound())) + v.LowerBound()	
+		if !given[i] {
+			vars[i] = (rand.Float64() * (v.UpperBound - v.LowerBound)) + v.LowerBound
+		}
 		fmt.Printf("\t%v = %v\n", v.Name, vars[i])
 	}
 
-	/* Evaluate every constraint at the randomly chosen point */
+	/* Evaluate every constraint at the starting point */
 	fmt.Printf("\nConstraint values:\n---\n")
 	for _, c := range p.Constraints() {
 		conVal, err := c.Value(vars)
@@ -95,7 +103,7 @@ func main() {
 		fmt.Printf("\t%v = %v (satisfied: %v)\n", c.Name, conVal, c.IsSatisfied(conVal))		
 	}
 
-	/* Evaluate every objective at the random point */
+	/* Evaluate every objective at the starting point */
 	fmt.Printf("\nObjective values:\n---\n")
 	for _, o := range p.Objectives() {
 		objVal, err := o.Value(vars)
@@ -106,7 +114,7 @@ func main() {
 		fmt.Printf("\t%v = %v\n", o.Name, objVal)		
 	}
 
-	/* Compute the gradient of every constraint at the randomly chosen point */
+	/* Compute the gradient of every constraint at the starting point */
 	fmt.Printf("\nConstraint gradients:\n---\n")
 	for _, c := range p.Constraints() {
 		conGrad, err := c.Gradient(vars)
@@ -117,7 +125,7 @@ func main() {
 		fmt.Printf("\t%v = %v\n", c.Name, conGrad)	
 	}
 
-	/* Compute the gradient of every objective at the random point */
+	/* Compute the gradient of every objective at the starting point */
 	fmt.Printf("\nObjective gradients:\n---\n")
 	for _, o := range p.Objectives() {
 		objGrad, err := o.Gradient(vars)
@@ -127,4 +135,17 @@ func main() {
 		}
 		fmt.Printf("\t%v = %v\n", o.Name, objGrad)
 	}
+
+	/* Write the problem to an MPS file, using the names from the .col and .row files */
+	mpsFile, err := os.Create("diet1.mps")
+	if err != nil {
+		fmt.Printf("Error creating MPS file: %v\n", err)
+		return
+	}
+	defer mpsFile.Close()
+	if err := p.WriteMPS(mpsFile); err != nil {
+		fmt.Printf("Error writing MPS file: %v\n", err)
+		return
+	}
+	fmt.Printf("\nWrote diet1.mps\n")
 }
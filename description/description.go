@@ -0,0 +1,223 @@
+package description
+
+/* A package describing the structure of an AMPL problem as JSON: its variables, constraints and objectives,
+   with their names, bounds, shapes and linear coefficients. It doesn't use cgo, so tools can read descriptions
+   without AMPL's solver library. Descriptions are written by `Problem.Description` in the model package.
+
+   The schema is versioned. Fields may be added without changing `SchemaVersion`, but a change to the meaning
+   of an existing field increments it. Infinite bounds are written as the strings "Infinity" and "-Infinity",
+   since JSON has no infinite numbers. */
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+/* The version of the schema written by this package */
+const SchemaVersion = 1
+
+/* Variable types */
+const (
+	VariableReal    = "real"
+	VariableInteger = "integer"
+	VariableBinary  = "binary"
+	VariableArc     = "arc"
+)
+
+/* Constraint senses */
+const (
+	SenseGreaterThan     = "ge"
+	SenseLessThan        = "le"
+	SenseEqualTo         = "eq"
+	SenseRange           = "range"
+	SenseNonBinding      = "free"
+	SenseComplementarity = "complements"
+)
+
+/* Objective senses */
+const (
+	ObjectiveMin = "min"
+	ObjectiveMax = "max"
+)
+
+/* Shapes of constraints and objectives */
+const (
+	ShapeConstant  = "constant"
+	ShapeLinear    = "linear"
+	ShapeQuadratic = "quadratic"
+	ShapeNonLinear = "nonlinear"
+)
+
+/* The structure of a problem, without the nonlinear expressions */
+type Problem struct {
+	Version     int          `json:"version"`
+	Name        string       `json:"name"`
+	Variables   []Variable   `json:"variables"`
+	Constraints []Constraint `json:"constraints"`
+	Objectives  []Objective  `json:"objectives"`
+}
+
+type Variable struct {
+	Index      int    `json:"index"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	LowerBound Bound  `json:"lower_bound"`
+	UpperBound Bound  `json:"upper_bound"`
+}
+
+type Constraint struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	Sense string `json:"sense"`
+	Shape string `json:"shape"`
+	Min   Bound  `json:"min"`
+	Max   Bound  `json:"max"`
+
+	/* The coefficients of the linear part. Variables that only appear nonlinearly have a coefficient of 0 */
+	Linear []LinearTerm `json:"linear"`
+
+	/* The index of the variable a complementarity constraint complements */
+	ComplementedVariable *int `json:"complemented_variable,omitempty"`
+}
+
+type Objective struct {
+	Index    int     `json:"index"`
+	Name     string  `json:"name"`
+	Sense    string  `json:"sense"`
+	Shape    string  `json:"shape"`
+	Constant float64 `json:"constant"`
+
+	/* The coefficients of the linear part. Variables that only appear nonlinearly have a coefficient of 0 */
+	Linear []LinearTerm `json:"linear"`
+}
+
+/* A variable index and its coefficient */
+type LinearTerm struct {
+	Variable    int     `json:"variable"`
+	Coefficient float64 `json:"coefficient"`
+}
+
+/* A bound, which is written as a string when it's infinite */
+type Bound float64
+
+func (b Bound) MarshalJSON() ([]byte, error) {
+	switch {
+	case math.IsInf(float64(b), 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(float64(b), -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return json.Marshal(float64(b))
+}
+
+func (b *Bound) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		switch s {
+		case "Infinity":
+			*b = Bound(math.Inf(1))
+		case "-Infinity":
+			*b = Bound(math.Inf(-1))
+		default:
+			return fmt.Errorf("Error: Invalid bound %q", s)
+		}
+		return nil
+	}
+	var x float64
+	if err := json.Unmarshal(data, &x); err != nil {
+		return fmt.Errorf("Error: Invalid bound %s", data)
+	}
+	*b = Bound(x)
+	return nil
+}
+
+/* Write the description as indented JSON */
+func (p *Problem) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+/* Read a description written by `Write`. Returns an error if it was written with a newer schema or refers to
+   variables that don't exist */
+func Read(r io.Reader) (*Problem, error) {
+	p := &Problem{}
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("Error: Can't read problem description: %v", err)
+	}
+	if p.Version < 1 || p.Version > SchemaVersion {
+		return nil, fmt.Errorf("Error: Unsupported problem description version %d", p.Version)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Problem) validate() error {
+	checkTerms := func(kind, name string, terms []LinearTerm) error {
+		for _, term := range terms {
+			if term.Variable < 0 || term.Variable >= len(p.Variables) {
+				return fmt.Errorf("Error: %s %s refers to variable %d, which doesn't exist", kind, name, term.Variable)
+			}
+		}
+		return nil
+	}
+	for i, v := range p.Variables {
+		if v.Index != i {
+			return fmt.Errorf("Error: Variable %s has index %d at position %d", v.Name, v.Index, i)
+		}
+	}
+	for i, c := range p.Constraints {
+		if c.Index != i {
+			return fmt.Errorf("Error: Constraint %s has index %d at position %d", c.Name, c.Index, i)
+		}
+		if err := checkTerms("Constraint", c.Name, c.Linear); err != nil {
+			return err
+		}
+		if v := c.ComplementedVariable; v != nil && (*v < 0 || *v >= len(p.Variables)) {
+			return fmt.Errorf("Error: Constraint %s complements variable %d, which doesn't exist", c.Name, *v)
+		}
+	}
+	for i, o := range p.Objectives {
+		if o.Index != i {
+			return fmt.Errorf("Error: Objective %s has index %d at position %d", o.Name, o.Index, i)
+		}
+		if err := checkTerms("Objective", o.Name, o.Linear); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Find a variable by name */
+func (p *Problem) Variable(name string) (Variable, bool) {
+	for _, v := range p.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+/* Find a constraint by name */
+func (p *Problem) Constraint(name string) (Constraint, bool) {
+	for _, c := range p.Constraints {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Constraint{}, false
+}
+
+/* Find an objective by name */
+func (p *Problem) Objective(name string) (Objective, bool) {
+	for _, o := range p.Objectives {
+		if o.Name == name {
+			return o, true
+		}
+	}
+	return Objective{}, false
+}
@@ -0,0 +1,61 @@
+package description
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testProblem() *Problem {
+	v := 0
+	return &Problem{
+		Version: SchemaVersion,
+		Name:    "test",
+		Variables: []Variable{
+			{0, "x", VariableReal, Bound(math.Inf(-1)), 10},
+			{1, "y", VariableBinary, 0, 1},
+		},
+		Constraints: []Constraint{
+			{0, "c", SenseComplementarity, ShapeLinear, 1, Bound(math.Inf(1)), []LinearTerm{{0, 1}, {1, -2}}, &v},
+		},
+		Objectives: []Objective{
+			{0, "f", ObjectiveMax, ShapeNonLinear, 3, []LinearTerm{{1, 1}}},
+		},
+	}
+}
+
+/* Write a description and read it back */
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	assert.Nil(testProblem().Write(&buf), "Write")
+	assert.Contains(buf.String(), `"lower_bound": "-Infinity"`, "Infinite lower bound")
+	assert.Contains(buf.String(), `"max": "Infinity"`, "Infinite upper bound")
+	p, err := Read(&buf)
+	assert.Nil(err, "Read")
+	assert.Equal(p, testProblem(), "Round trip")
+
+	v, ok := p.Variable("y")
+	assert.True(ok, "Find variable")
+	assert.Equal(v.Index, 1, "Variable index")
+	_, ok = p.Constraint("d")
+	assert.False(ok, "Missing constraint")
+	o, ok := p.Objective("f")
+	assert.True(ok, "Find objective")
+	assert.Equal(o.Constant, 3.0, "Objective constant")
+}
+
+/* Reject descriptions from newer schemas and ones that refer to missing variables */
+func TestReadErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := Read(strings.NewReader(`{"version": 2}`))
+	assert.Error(err, "Newer version")
+	_, err = Read(strings.NewReader(`{"version": 1, "variables": [], "constraints": [{"index": 0, "linear": [{"variable": 0}]}]}`))
+	assert.Error(err, "Missing variable")
+	_, err = Read(strings.NewReader(`{"version": 1, "variables": [{"index": 0, "lower_bound": "big"}]}`))
+	assert.Error(err, "Bad bound")
+	_, err = Read(strings.NewReader(`not json`))
+	assert.Error(err, "Not JSON")
+}
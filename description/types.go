@@ -0,0 +1,65 @@
+package description
+
+import (
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+/* Get the name the schema uses for a variable type */
+func DescribeVariableType(t types.VariableType) string {
+	switch t {
+	case types.VariableInteger:
+		return VariableInteger
+	case types.VariableBinary:
+		return VariableBinary
+	case types.VariableArc:
+		return VariableArc
+	}
+	return VariableReal
+}
+
+/* Get the name the schema uses for a constraint sense */
+func DescribeConstraintSense(s types.ConstraintSense) string {
+	switch s {
+	case types.ConstraintGreaterThan:
+		return SenseGreaterThan
+	case types.ConstraintLessThan:
+		return SenseLessThan
+	case types.ConstraintEqualTo:
+		return SenseEqualTo
+	case types.ConstraintRange:
+		return SenseRange
+	case types.ConstraintComplementarity:
+		return SenseComplementarity
+	}
+	return SenseNonBinding
+}
+
+/* Get the name the schema uses for an objective sense */
+func DescribeObjectiveSense(s types.ObjectiveSense) string {
+	if s == types.ObjectiveMax {
+		return ObjectiveMax
+	}
+	return ObjectiveMin
+}
+
+/* Get the name the schema uses for the shape of a constraint or objective */
+func DescribeShape(s types.Shape) string {
+	switch s {
+	case types.Constant:
+		return ShapeConstant
+	case types.Linear:
+		return ShapeLinear
+	case types.Quadratic:
+		return ShapeQuadratic
+	}
+	return ShapeNonLinear
+}
+
+/* Describe linear terms by variable index */
+func DescribeTerms(terms []types.LinearTerm) []LinearTerm {
+	described := make([]LinearTerm, len(terms))
+	for i, term := range terms {
+		described[i] = LinearTerm{Variable: term.Variable.Index, Coefficient: term.Coefficient}
+	}
+	return described
+}
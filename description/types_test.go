@@ -0,0 +1,19 @@
+package description
+
+import (
+	"github.com/alanctgardner/ampl-go/types"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+/* The shared types are written with the names the schema uses */
+func TestDescribeTypes(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(DescribeVariableType(types.VariableBinary), VariableBinary, "Variable type")
+	assert.Equal(DescribeConstraintSense(types.ConstraintComplementarity), SenseComplementarity, "Constraint sense")
+	assert.Equal(DescribeConstraintSense(types.ConstraintNonBinding), SenseNonBinding, "Non-binding")
+	assert.Equal(DescribeObjectiveSense(types.ObjectiveMax), ObjectiveMax, "Objective sense")
+	assert.Equal(DescribeShape(types.Quadratic), ShapeQuadratic, "Shape")
+	x := types.Variable{Name: "x", Index: 3}
+	assert.Equal(DescribeTerms([]types.LinearTerm{{Variable: x, Coefficient: 2}}), []LinearTerm{{Variable: 3, Coefficient: 2}}, "Linear terms")
+}
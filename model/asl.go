@@ -8,56 +8,141 @@ package model
 #include "asl.h"
 #include "psinfo.h"
 #include "nlp2.h"
-
-Jmp_buf *null = 0;
+#include <stdlib.h>
+#include <string.h>
 
 // Helper functions to call gradient and value function pointers
-// Go doesn't support C function pointers
+// Go doesn't support C function pointers. cur_ASL is thread-local, and goroutines can move
+// between threads from one cgo call to the next, so it's set in the same call as the evaluation
 typedef real (valFunc) (ASL* asl, int n, real *X, fint *nerror);
 typedef void (grdFunc) (ASL* asl, int n, real *X, real *G, fint *nerror);
 typedef void (jacFunc) (ASL* asl, real *X, real *J, fint *nerror);
 
 real callValFunc(void *f, ASL *asl, int n, real *X, fint *nerror) {
+	cur_ASL = asl;
+	asl->i.evalerr_.who = 0;
 	return ((valFunc*)f)(asl, n, X, nerror);
 }
 
 void callGrdFunc(void *f, ASL *asl, int n, real *X, real *G, fint *nerror) {
+	cur_ASL = asl;
+	asl->i.evalerr_.who = 0;
 	return ((grdFunc*)f)(asl, n, X, G, nerror);
 }
 
 void callJacFunc(void *f, ASL *asl, real *X, real *J, fint *nerror) {
+	cur_ASL = asl;
+	asl->i.evalerr_.who = 0;
 	return ((jacFunc*)f)(asl, X, J, nerror);
 }
 
+// Return everything written to a temporary Stderr as a string the caller must free
+char *readCapturedStderr(FILE *f) {
+	char *s;
+	long n;
+
+	if (!f) {
+		s = malloc(1);
+		*s = 0;
+		return s;
+	}
+	fflush(f);
+	n = ftell(f);
+	rewind(f);
+	s = malloc(n + 1);
+	n = fread(s, 1, n, f);
+	s[n] = 0;
+	fclose(f);
+	return s;
+}
+
+// Read a .nl file with fg_read or pfgh_read, depending on how asl was allocated, returning one of the
+// ASL_readerr codes instead of exiting when the file can't be read. The messages AMPL prints are returned
+// in *msg, which the caller must free
+int readProblem(ASL *asl, char *stub, int flags, long *line, char **msg) {
+	Jmp_buf jb;
+	FILE *saved, *captured;
+	FILE * volatile nl = 0;
+	int rv;
+
+	*line = 0;
+	cur_ASL = asl;
+	saved = Stderr;
+	if ((captured = tmpfile()))
+		Stderr = captured;
+	asl->i.return_nofile_ = 1;
+	asl->i.err_jmp_ = &jb;
+	if (__builtin_setjmp(jb.jb)) {
+		rv = jb.err;
+		*line = jb.line;
+		if (nl)
+			fclose(nl);
+	} else if (!(nl = jac0dim_ASL(asl, stub, (ftnlen)strlen(stub)))) {
+		fprintf(Stderr, "can't open %s\n", asl->i.filename_);
+		rv = ASL_readerr_nofile;
+	} else if (asl->i.ASLtype == ASL_read_fg) {
+		rv = fg_read_ASL(asl, nl, flags);
+	} else {
+		rv = pfgh_read_ASL(asl, nl, flags);
+	}
+	asl->i.err_jmp_ = 0;
+	asl->i.err_jmp1_ = 0;
+	Stderr = saved;
+	*msg = readCapturedStderr(captured);
+	return rv;
+}
+
 */
 import "C"
 
 import (
 	"fmt"
 	"math"
+	"runtime"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
 // A placeholder for +/-Inf for finite calculations
 var Plinfy = 1.0e10
 
+/* Variable bounds are clamped to +/-Plinfy, so treat those as infinite. Constraint bounds aren't clamped and
+   are +/-Inf when missing */
+func isInfiniteVariableBound(x float64) bool {
+	return math.Abs(x) >= Plinfy
+}
+
 // The feasibility tolerance to check whether constraints are satisfied
 var Featol = 1.0e-6
 
+/* A problem loaded from a `.nl` file. A Problem can be used from several goroutines, but its evaluations
+   run one at a time; use `Clone` to evaluate the same problem in parallel */
 type Problem struct {
-	Name    string
-	asl     *C.struct_ASL
-	aslPfgh *C.struct_ASL_pfgh
+	Name        string
+	path        string
+	mu          sync.Mutex
+	asl         *C.struct_ASL
+	aslPfgh     *C.struct_ASL_pfgh
+	hessian     *hessianSetup
+	options     LoadOptions
+	sufStrings  []*C.char
+	outSuffixes []*Suffix
 }
 
 /* Get the list of Constraints in this problem */
 func (p *Problem) Constraints() []Constraint {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
 	numConstraints := int(p.asl.i.n_con_)
 	constraints := make([]Constraint, numConstraints)
 	bounds := (*[1 << 30]C.real)(unsafe.Pointer(p.asl.i.LUrhs_))[:numConstraints*2 : numConstraints*2]
 	cgradList := (*[1 << 30]*C.struct_cgrad)(unsafe.Pointer(p.asl.i.Cgrad_))[:numConstraints:numConstraints]
 	cClassList := (*[1 << 30]C.char)(unsafe.Pointer(p.aslPfgh.I.c_class))[:numConstraints:numConstraints]
-	vars := p.Variables()
+	cvarList := p.cvar()
+	vars := p.variables()
 	for i := 0; i < numConstraints; i++ {
 		name := C.GoString(C.con_name_ASL(p.asl, C.int(i)))
 
@@ -77,6 +162,11 @@ func (p *Problem) Constraints() []Constraint {
 		} else {
 			conType = ConstraintNonBinding
 		}
+		// mpec_adjust turns complementarity constraints into equalities and pairs the variables instead, and
+		// can add constraints past the ones cvar covers
+		if p.asl.i.ccind1 == nil && i < len(cvarList) && cvarList[i] > 0 {
+			conType = ConstraintComplementarity
+		}
 
 		constraints[i].Name = name
 		constraints[i].Sense = conType
@@ -97,13 +187,17 @@ func (p *Problem) Constraints() []Constraint {
 
 /* Get the list of Objectives in this problem */
 func (p *Problem) Objectives() []Objective {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
 	numObjectives := int(p.asl.i.n_obj_)
 
 	objectives := make([]Objective, numObjectives)
 	objectiveSenses := (*[1 << 30]byte)(unsafe.Pointer(p.asl.i.objtype_))[:numObjectives:numObjectives]
 	ogradList := (*[1 << 30]*C.struct_ograd)(unsafe.Pointer(p.asl.i.Ograd_))[:numObjectives:numObjectives]
 	oClassList := (*[1 << 30]C.char)(unsafe.Pointer(p.aslPfgh.I.o_class))[:numObjectives:numObjectives]
-	vars := p.Variables()
+	vars := p.variables()
 	for i := 0; i < numObjectives; i++ {
 		name := C.GoString(C.obj_name_ASL(p.asl, C.int(i)))
 		objectives[i].Name = name
@@ -122,95 +216,251 @@ func (p *Problem) Objectives() []Objective {
 	return objectives
 }
 
+/* Build an EvalError from the details AMPL recorded about the last failed evaluation */
+func (p *Problem) evalError(code C.fint) error {
+	info := &p.asl.i.evalerr_
+	coIndex := int(p.asl.i.co_index_)
+	e := &EvalError{Derivative: int(code) - 1}
+	if info.who != nil {
+		coIndex = int(info.coi)
+		// Operators are recorded with a trailing ' or " for their derivatives, and division with padding
+		e.Operator = strings.TrimSpace(strings.TrimRight(C.GoString(info.who), "'\""))
+		e.Derivative = int(info.jv) - 1
+		e.Message = C.GoString(&info.msg[0])
+		args := []float64{float64(info.a), float64(info.b)}
+		e.Args = args[:int(info.nargs)]
+	}
+	if e.Derivative < 0 {
+		e.Derivative = 0
+	}
+	if coIndex >= 0 {
+		e.Objective, e.Constraint = -1, coIndex
+	} else {
+		e.Objective, e.Constraint = -(coIndex + 1), -1
+	}
+	return e
+}
+
 func (p *Problem) objValue(index int, x []float64) (float64, error) {
+	if err := p.acquire(); err != nil {
+		return 0, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	if len(x) != numVariables {
-		return 0, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return 0, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	val := C.callValFunc(unsafe.Pointer(p.asl.p.Objval), p.asl, C.int(index), (*C.real)(unsafe.Pointer(&x[0])), &err)
 	if err != 0 {
-		return 0, fmt.Errorf("Error: %i when evaluating objective value", err)
+		return 0, p.evalError(err)
 	}
 	return float64(val), nil
 }
 
 func (p *Problem) objGrad(index int, x []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	if len(x) != numVariables {
-		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	grad := make([]float64, numVariables)
 	C.callGrdFunc(unsafe.Pointer(p.asl.p.Objgrd), p.asl, C.int(index), (*C.real)(unsafe.Pointer(&x[0])), (*C.real)(unsafe.Pointer(&grad[0])), &err)
-	fmt.Printf("Error: %v\n", err)
 	if err != 0 {
-		return nil, fmt.Errorf("Error: %i when evaluating objective gradient", err)
+		return nil, p.evalError(err)
 	}
 	return grad, nil
 }
 
 func (p *Problem) conValue(index int, x []float64) (float64, error) {
+	if err := p.acquire(); err != nil {
+		return 0, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	if len(x) != numVariables {
-		return 0, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return 0, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	val := C.callValFunc(unsafe.Pointer(p.asl.p.Conival), p.asl, C.int(index), (*C.real)(unsafe.Pointer(&x[0])), &err)
 	if err != 0 {
-		return 0, fmt.Errorf("Error: %i when evaluating constraint value", err)
+		return 0, p.evalError(err)
 	}
-	return float64(val), nil
+	// conpival applies the variable scales but not the constraint scale
+	return float64(val) * p.constraintScale(index), nil
 }
 
 func (p *Problem) conGrad(index int, x []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	if len(x) != numVariables {
-		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	grad := make([]float64, numVariables)
 	C.callGrdFunc(unsafe.Pointer(p.asl.p.Congrd), p.asl, C.int(index), (*C.real)(unsafe.Pointer(&x[0])), (*C.real)(unsafe.Pointer(&grad[0])), &err)
 	if err != 0 {
-		return nil, fmt.Errorf("Error: %i when evaluating constraint value", err)
+		return nil, p.evalError(err)
+	}
+	scale := p.constraintScale(index)
+	for i := range grad {
+		grad[i] *= scale
 	}
 	return grad, nil
 }
 
 /* Evaluate the value of all constraints at point x */
 func (p *Problem) ConstraintValues(x []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	numConstraints := int(p.asl.i.n_con_)
 	if len(x) != numVariables {
-		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	vals := make([]float64, numConstraints)
 	C.callJacFunc(unsafe.Pointer(p.asl.p.Conval), p.asl, (*C.real)(unsafe.Pointer(&x[0])), (*C.real)(unsafe.Pointer(&vals[0])), &err)
 	if err != 0 {
-		return nil, fmt.Errorf("Error: %i when evaluating constraint values", err)
+		return nil, p.evalError(err)
 	}
 	return vals, nil
 }
 
 /* Evaluate the Jacobian of the constraints */
 func (p *Problem) ConstraintJacobian(x []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
 	numVariables := int(p.asl.i.n_var_)
 	numConstraints := int(p.asl.i.n_con_)
 	if len(x) != numVariables {
-		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %i, got %i", numVariables, len(x))
+		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
 	}
 	var err C.fint
 	vals := make([]float64, numConstraints*numVariables)
 	C.callJacFunc(unsafe.Pointer(p.asl.p.Jacval), p.asl, (*C.real)(unsafe.Pointer(&x[0])), (*C.real)(unsafe.Pointer(&vals[0])), &err)
 	if err != 0 {
-		return nil, fmt.Errorf("Error: %i when evaluating constraint Jacobian", err)
+		return nil, p.evalError(err)
 	}
 	return vals, nil
 }
 
+/* Get the number of nonzeros in the sparse constraint Jacobian */
+func (p *Problem) JacobianNonZeros() int {
+	if p.acquire() != nil {
+		return 0
+	}
+	defer p.release()
+	return int(p.asl.i.nzc_)
+}
+
+/* Get the sparsity structure of the constraint Jacobian. Entry k of `rows` and `cols` gives the constraint
+   and variable index of the k-th nonzero, in the same order `JacobianValues` fills its buffer */
+func (p *Problem) JacobianStructure() ([]int, []int) {
+	if p.acquire() != nil {
+		return nil, nil
+	}
+	defer p.release()
+	numConstraints := int(p.asl.i.n_con_)
+	numNonZeros := int(p.asl.i.nzc_)
+	rows := make([]int, numNonZeros)
+	cols := make([]int, numNonZeros)
+	if numConstraints == 0 {
+		return rows, cols
+	}
+	cgradList := (*[1 << 30]*C.struct_cgrad)(unsafe.Pointer(p.asl.i.Cgrad_))[:numConstraints:numConstraints]
+	for i := 0; i < numConstraints; i++ {
+		for gradPtr := cgradList[i]; gradPtr != nil; gradPtr = gradPtr.next {
+			rows[gradPtr.goff] = i
+			cols[gradPtr.goff] = int(gradPtr.varno)
+		}
+	}
+	return rows, cols
+}
+
+/* Evaluate the nonzeros of the constraint Jacobian at point x into `jac`, which must have `JacobianNonZeros` entries.
+   The order of the values matches the indices returned by `JacobianStructure` */
+func (p *Problem) JacobianValues(x []float64, jac []float64) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	numNonZeros := int(p.asl.i.nzc_)
+	if len(x) != numVariables {
+		return fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
+	}
+	if len(jac) != numNonZeros {
+		return fmt.Errorf("Error: Incorrect size of Jacobian buffer: expected %d, got %d", numNonZeros, len(jac))
+	}
+	if numNonZeros == 0 {
+		return nil
+	}
+	var err C.fint
+	C.callJacFunc(unsafe.Pointer(p.asl.p.Jacval), p.asl, (*C.real)(unsafe.Pointer(&x[0])), (*C.real)(unsafe.Pointer(&jac[0])), &err)
+	if err != 0 {
+		return p.evalError(err)
+	}
+	return nil
+}
+
+/* Get the starting point AMPL wrote to the `.nl` file, along with a mask that is true for the variables the user
+   gave an initial value. The other variables are 0 */
+func (p *Problem) InitialPrimal() ([]float64, []bool) {
+	if p.acquire() != nil {
+		return nil, nil
+	}
+	defer p.release()
+	return initialValues(p.asl.i.X0_, p.asl.i.havex0_, int(p.asl.i.n_var_))
+}
+
+/* Get the initial dual values AMPL wrote to the `.nl` file, along with a mask that is true for the constraints the
+   user gave an initial dual value. The other constraints are 0 */
+func (p *Problem) InitialDual() ([]float64, []bool) {
+	if p.acquire() != nil {
+		return nil, nil
+	}
+	defer p.release()
+	return initialValues(p.asl.i.pi0_, p.asl.i.havepi0_, int(p.asl.i.n_con_))
+}
+
+/* Copy initial values out of the ASL structure. AMPL only allocates them if the `.nl` file had some */
+func initialValues(vals *C.real, have *C.char, n int) ([]float64, []bool) {
+	values := make([]float64, n)
+	given := make([]bool, n)
+	if vals == nil || n == 0 {
+		return values, given
+	}
+	valList := (*[1 << 30]C.real)(unsafe.Pointer(vals))[:n:n]
+	haveList := (*[1 << 30]C.char)(unsafe.Pointer(have))[:n:n]
+	for i := 0; i < n; i++ {
+		values[i] = float64(valList[i])
+		given[i] = haveList[i] != 0
+	}
+	return values, given
+}
+
 /* Get the list of Variables in this problem */
 func (p *Problem) Variables() []Variable {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	return p.variables()
+}
+
+func (p *Problem) variables() []Variable {
 	numVariables := int(p.asl.i.n_var_)
 	numNonLinear := intMax(int(p.asl.i.nlvc_), int(p.asl.i.nlvo_))
 	numBoth := int(p.asl.i.nlvb_)
@@ -343,16 +593,98 @@ func (p *Problem) Variables() []Variable {
 	return variables
 }
 
-/* Load a problem from a `.nl` file */
-func ProblemFromFile(path string) *Problem {
+/* Load a problem from a `.nl` file. Returns a *ReadError if AMPL is unable to read the file */
+func LoadProblem(path string) (*Problem, error) {
+	return LoadProblemWithOptions(path, LoadOptions{})
+}
+
+/* Load a problem from a `.nl` file, reading the values AMPL sent for the declared suffixes. Returns a *ReadError
+   if AMPL is unable to read the file */
+func LoadProblemWithSuffixes(path string, suffixes []SuffixDecl) (*Problem, error) {
+	return LoadProblemWithOptions(path, LoadOptions{Suffixes: suffixes})
+}
+
+/* Options for reading a `.nl` file */
+type LoadOptions struct {
+	/* The suffixes to read. AMPL's values for them are available from `Suffix` */
+	Suffixes []SuffixDecl
+
+	/* Apply AMPL's mpec_adjust, which rewrites the complementarity constraints so each pairs two variables,
+	   v1 >= 0 complements v2 >= 0. This adds variables and constraints, see `ComplementarityPairs` */
+	MPECAdjust bool
+
+	/* Accept logical constraints, such as indicator constraints, instead of failing to read the file.
+	   See `IndicatorConstraints` */
+	LogicalConstraints bool
+}
+
+/* Load a problem from a `.nl` file with the given options. Returns a *ReadError if AMPL is unable to read the file */
+func LoadProblemWithOptions(path string, opts LoadOptions) (*Problem, error) {
 	pathC := C.CString(path)
+	defer C.free(unsafe.Pointer(pathC))
+	aslLock.Lock()
+	defer aslLock.Unlock()
 	asl := C.ASL_alloc(C.ASL_read_pfgh)
-	nl := C.jac0dim_ASL(asl, pathC, C.ftnlen(len(path)))
-	C.pfgh_read_ASL(asl, nl, C.ASL_find_o_class|C.ASL_find_c_class)
-	asl.i.err_jmp_ = C.null
-	asl.i.err_jmp1_ = C.null
+	// The suffix names have to live as long as the ASL structure
+	sufStrings := declareSuffixes(asl, opts.Suffixes)
+	// Keep the initial primal and dual values, with masks of which ones were given
+	asl.i.want_xpi0_ = 7
+	var line C.long
+	var msg *C.char
+	flags := C.int(C.ASL_find_o_class | C.ASL_find_c_class)
+	if opts.MPECAdjust {
+		flags |= C.ASL_cc_simplify
+	}
+	if opts.LogicalConstraints {
+		flags |= C.ASL_allow_CLP
+	}
+	code := C.readProblem(asl, pathC, flags, &line, &msg)
+	message := strings.TrimSpace(C.GoString(msg))
+	C.free(unsafe.Pointer(msg))
+	if code != C.ASL_readerr_none {
+		C.ASL_free(&asl)
+		freeCStrings(sufStrings)
+		return nil, &ReadError{Path: path, Kind: ReadErrorKind(code), Line: int(line), Message: message}
+	}
+	if opts.MPECAdjust {
+		// Give the variables and constraints mpec_adjust added generic names instead of errors
+		asl.i.n_var1 = asl.i.n_var_
+		asl.i.n_con1 = asl.i.n_con_
+	}
 	aslPfgh := (*C.ASL_pfgh)(unsafe.Pointer(asl))
-	return &Problem{path, asl, aslPfgh}
+	p := &Problem{Name: path, path: path, asl: asl, aslPfgh: aslPfgh, options: opts, sufStrings: sufStrings}
+	// Free the ASL structure if the caller forgets to close the problem
+	runtime.SetFinalizer(p, (*Problem).Close)
+	return p, nil
+}
+
+/* Free the memory AMPL allocated for the problem. Evaluations on a closed problem, or on its Objectives and
+   Constraints, return ErrClosed, and the other methods return empty results. Closing twice has no effect */
+func (p *Problem) Close() error {
+	aslLock.Lock()
+	defer aslLock.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.asl == nil {
+		return nil
+	}
+	runtime.SetFinalizer(p, nil)
+	C.ASL_free(&p.asl)
+	freeCStrings(p.sufStrings)
+	p.aslPfgh = nil
+	p.hessian = nil
+	p.sufStrings = nil
+	return nil
+}
+
+/* Load a problem from a `.nl` file. Panics with a *ReadError if AMPL is unable to read the file,
+   use `LoadProblem` to handle the error instead */
+func ProblemFromFile(path string) *Problem {
+	p, err := LoadProblem(path)
+	if err != nil {
+		panic(err)
+	}
+	return p
 }
 
 /* Return the larger integer */
@@ -89,7 +89,7 @@ typedef Long ftnlen;
 
  typedef size_t (*Fwrite)(const void*, size_t, size_t, FILE*);
  typedef int (*Add_Indicator)(void*, int, int, int, int, int*, real*, real);
- typedef struct {jmp_buf jb; int err;} Jmp_buf;
+ typedef struct {jmp_buf jb; int err; long line;} Jmp_buf;
  typedef struct ASL ASL;
  typedef struct MPEC_Adjust MPEC_Adjust;
  typedef struct Objrep Objrep;
@@ -348,6 +348,16 @@ Exitcall {
 
  typedef struct DerivErrInfo DerivErrInfo;
 
+ typedef struct
+EvalErrInfo {	/* details of the last evaluation error */
+	const char *who;	/* operator or imported function that failed */
+	real a, b;		/* arguments to who */
+	int nargs;		/* number of arguments stored in a and b */
+	int coi;		/* co_index when the error was detected */
+	int jv;			/* 1 = value, 2 = gradient, 3 = Hessian */
+	char msg[256];		/* message from an imported function */
+	} EvalErrInfo;
+
  typedef struct
 Edaginfo {
 	int ASLtype;
@@ -609,6 +619,13 @@ Edaginfo {
 
 	/* for derivative errors */
 	DerivErrInfo *Derrs, *Derrs0;
+
+	/* for reporting evaluation errors to the caller */
+	Jmp_buf	*err_jmp2_;	/* If nonzero when an error is detected */
+				/* (and err_jmp_ == 0), longjmp here without */
+				/* printing an error message.  Unlike err_jmp_, */
+				/* this is not reset by the evaluation routines. */
+	EvalErrInfo evalerr_;	/* details of the error, set before longjmp */
 	} Edaginfo;
 
  struct
@@ -681,6 +698,7 @@ TMInfo {
 #define cvar		asl->i.cvar_
 #define err_jmp		asl->i.err_jmp_
 #define err_jmp1	asl->i.err_jmp1_
+#define err_jmp2	asl->i.err_jmp2_
 #define fhash		asl->i.fhash_
 #define filename	asl->i.filename_
 #define funcs		asl->i.funcs_
@@ -785,7 +803,11 @@ TMInfo {
  extern real Infinity, edagread_one, negInfinity;
  extern char g_fmt_E, *progname;
  extern int g_fmt_decpt;
- extern ASL *cur_ASL;
+/* cur_ASL is per thread, so different ASLs can be evaluated in parallel */
+#ifndef ASL_THREAD_LOCAL
+#define ASL_THREAD_LOCAL __thread
+#endif
+ extern ASL_THREAD_LOCAL ASL *cur_ASL;
 
 enum { /* mode bits for ASLtype */
 	ASL_read_f	= 1,
@@ -887,7 +909,8 @@ enum ASL_reader_error_codes {
 	ASL_readerr_unavail= 4, /* user-defined function not available */
 	ASL_readerr_corrupt= 5, /* corrupt .nl file */
 	ASL_readerr_bug	   = 6,	/* bug in .nl reader */
-	ASL_readerr_CLP    = 7  /* solver cannot handle CLP extensions */
+	ASL_readerr_CLP    = 7, /* solver cannot handle CLP extensions */
+	ASL_readerr_nomem  = 8  /* ran out of memory */
 	};
 
 enum ASL_suf_sos_flags { /* bits in flags parameter of suf_sos() */
@@ -0,0 +1,96 @@
+package model
+
+/*
+#include "asl.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+/* Get the cvar array AMPL reads for complementarity constraints, or nil if there are none. Entry i is the
+   variable index + 1 that constraint i complements, or 0 */
+func (p *Problem) cvar() []C.int {
+	if p.asl.i.cvar_ == nil {
+		return nil
+	}
+	numConstraints := int(p.asl.i.n_con0)
+	return (*[1 << 30]C.int)(unsafe.Pointer(p.asl.i.cvar_))[:numConstraints:numConstraints]
+}
+
+/* Get the index of the variable each constraint complements, or -1 for constraints that aren't complementarity
+   constraints. The pairing is kept when the problem is loaded with `MPECAdjust`, although the constraints are
+   rewritten and `ComplementarityPairs` holds the complementarities AMPL solvers work with */
+func (p *Problem) ComplementedVariables() []int {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	vars := make([]int, int(p.asl.i.n_con_))
+	for i := range vars {
+		vars[i] = -1
+	}
+	for i, v := range p.cvar() {
+		vars[i] = int(v) - 1
+	}
+	return vars
+}
+
+/* Get the pairs of variables that complement each other after `mpec_adjust`, as (v1, v2) for v1 >= 0
+   complements v2 >= 0. Returns nil unless the problem was loaded with `MPECAdjust` */
+func (p *Problem) ComplementarityPairs() [][2]int {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	if p.asl.i.ccind1 == nil {
+		return nil
+	}
+	n := int(p.asl.i.n_cc_)
+	fortran := int(p.asl.i.Fortran_)
+	ind1 := (*[1 << 30]C.int)(unsafe.Pointer(p.asl.i.ccind1))[:n:n]
+	ind2 := (*[1 << 30]C.int)(unsafe.Pointer(p.asl.i.ccind2))[:n:n]
+	pairs := make([][2]int, n)
+	for k := range pairs {
+		pairs[k] = [2]int{int(ind1[k]) - fortran, int(ind2[k]) - fortran}
+	}
+	return pairs
+}
+
+/* Get the variable a complementarity constraint complements. Returns false if the constraint isn't a
+   complementarity constraint */
+func (c Constraint) ComplementedVariable() (Variable, bool) {
+	if c.Sense != ConstraintComplementarity {
+		return Variable{}, false
+	}
+	vars := c.p.ComplementedVariables()
+	if vars == nil || vars[c.Index] < 0 {
+		return Variable{}, false
+	}
+	return c.p.Variables()[vars[c.Index]], true
+}
+
+/* Compute how far a complementarity constraint is from being satisfied at point x. For the constraint body
+   F(x), less its finite bound, complementing variable v with bounds [l, u] this is |x[v] - mid(l, x[v] - F(x), u)|,
+   which is 0 when F(x) >= 0 with x[v] at l, F(x) <= 0 with x[v] at u, or F(x) = 0 in between */
+func (c Constraint) ComplementarityResidual(x []float64) (float64, error) {
+	v, ok := c.ComplementedVariable()
+	if !ok {
+		return 0, fmt.Errorf("Error: Constraint %s is not a complementarity constraint", c.Name)
+	}
+	f, err := c.Value(x)
+	if err != nil {
+		return 0, err
+	}
+	if !math.IsInf(c.Min, 0) {
+		f -= c.Min
+	} else if !math.IsInf(c.Max, 0) {
+		f -= c.Max
+	}
+	xv := x[v.Index]
+	projected := math.Min(math.Max(xv-f, v.LowerBound), v.UpperBound)
+	return math.Abs(xv - projected), nil
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+const testMPECModelFile = "mpec.nl"
+
+/* The same problem, with a lower bound of 1 on the complemented variable */
+const testMPECBoundModelFile = "mpec_lb.nl"
+
+/* Get the variable a complementarity constraint is paired with */
+func TestComplementarity(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testMPECModelFile)
+	vars := p.Variables()
+	cons := p.Constraints()
+	assert.Equal(cons[0].Sense, ConstraintComplementarity, "Complementarity constraint")
+	assert.Equal(cons[0].Min, -1.0, "Constant moved to the bound")
+	assert.True(math.IsInf(cons[0].Max, 1), "No upper bound")
+	assert.Equal(cons[1].Sense, ConstraintGreaterThan, "Normal constraint")
+	assert.Equal(p.ComplementedVariables(), []int{1, -1}, "Complemented variables")
+	assert.Nil(p.ComplementarityPairs(), "Not adjusted")
+
+	v, ok := cons[0].ComplementedVariable()
+	assert.True(ok, "Complemented variable")
+	assert.Equal(v, vars[1], "Complemented variable")
+	_, ok = cons[1].ComplementedVariable()
+	assert.False(ok, "No complemented variable")
+}
+
+/* Check the complementarity residual at points that satisfy the complementarity and one that doesn't */
+func TestComplementarityResidual(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testMPECModelFile)
+	cons := p.Constraints()
+	for _, test := range []struct {
+		x        []float64
+		residual float64
+	}{
+		{[]float64{0, 1}, 0},
+		{[]float64{1, 0}, 0},
+		{[]float64{1, 1}, 1},
+		{[]float64{0, 2}, 1},
+	} {
+		r, err := cons[0].ComplementarityResidual(test.x)
+		assert.Nil(err, "No error")
+		assert.Equal(r, test.residual, "Residual at %v", test.x)
+	}
+	_, err := cons[1].ComplementarityResidual([]float64{0, 0})
+	assert.NotNil(err, "Not a complementarity constraint")
+}
+
+/* Rewrite the complementarity with mpec_adjust */
+func TestMPECAdjust(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblemWithOptions(testMPECModelFile, LoadOptions{MPECAdjust: true})
+	assert.Nil(err, "No error")
+	defer p.Close()
+	vars := p.Variables()
+	assert.Equal(len(vars), 3, "Added a variable")
+	assert.Equal(vars[2].Name, "_svar[3]", "Generic name")
+	assert.Equal(vars[2].LowerBound, 0.0, "Nonnegative")
+	cons := p.Constraints()
+	assert.Equal(cons[0].Sense, ConstraintEqualTo, "Rewritten as an equality")
+	assert.Equal(len(cons[0].Variables), 3, "Uses the new variable")
+	assert.Equal(p.ComplementarityPairs(), [][2]int{{1, 2}}, "Complementarity pairs")
+	assert.Equal(p.ComplementedVariables(), []int{1, -1}, "Original pairing")
+
+	clone, err := p.Clone()
+	assert.Nil(err, "No error")
+	defer clone.Close()
+	assert.Equal(len(clone.Variables()), 3, "Clone is adjusted too")
+}
+
+/* mpec_adjust adds constraints when the complemented variable has a nonzero lower bound */
+func TestMPECAdjustBound(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblemWithOptions(testMPECBoundModelFile, LoadOptions{MPECAdjust: true})
+	assert.Nil(err, "No error")
+	defer p.Close()
+	cons := p.Constraints()
+	assert.True(len(cons) > 2, "Added constraints")
+	for _, c := range cons {
+		assert.NotEqual(c.Sense, ConstraintComplementarity, "Rewritten")
+		_, ok := c.ComplementedVariable()
+		assert.False(ok, "No complemented variable")
+	}
+	vars := p.ComplementedVariables()
+	assert.Equal(len(vars), len(cons), "One entry per constraint")
+	assert.Equal(vars[:2], []int{1, -1}, "Original pairing")
+	for _, v := range vars[2:] {
+		assert.Equal(v, -1, "Added constraints")
+	}
+	shifted := cons[2]
+	assert.Equal(shifted.Sense, ConstraintEqualTo, "Shifts the complemented variable")
+	assert.Equal(shifted.Min, 1.0, "By its lower bound")
+	assert.Equal(p.ComplementarityPairs(), [][2]int{{2, 3}}, "Pairs the shifted variable")
+	d := p.Description()
+	assert.Equal(len(d.Constraints), len(cons), "Describes the added constraints")
+	assert.Equal(*d.Constraints[0].ComplementedVariable, 1, "Original pairing")
+	assert.Nil(d.Constraints[2].ComplementedVariable, "Added constraint")
+}
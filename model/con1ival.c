@@ -142,6 +142,8 @@ con1grd(ASL *a, int i, real *X, real *G, fint *nerror)
 		}
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, i, 1);
+	if (nerror && *nerror > 0)
+		return;
 	if (!(x0kind & ASL_have_funnel)) {
 		if (f_b)
 			funnelset_ASL(asl, f_b);
@@ -132,6 +132,8 @@ jac2val_ASL(ASL *a, real *X, real *G, fint *nerror)
 	k = n_conjac[1];
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, j, k-j);
+	if (ne0 >= 0 && *nerror)
+		goto done;
 	Adjoints = adjoints;
 	d0 = con_de;
 	cscale = asl->i.cscale;
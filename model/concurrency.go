@@ -0,0 +1,60 @@
+package model
+
+import (
+	"sync"
+)
+
+/* Concurrency model
+
+   AMPL keeps some global state, so the package coordinates access to it:
+
+   - Loading and closing problems changes AMPL's global state, and is serialized with aslLock.
+   - Evaluations only touch the ASL structure of their own problem (cur_ASL is thread-local), so
+     evaluations on different Problems run in parallel.
+   - The expression graph of a problem holds the values of the last evaluation, so evaluations on
+     one Problem, including through its Objectives and Constraints, take turns on the Problem's mutex.
+
+   To evaluate one problem from several goroutines at once, e.g. for a multistart search, give each
+   goroutine its own copy of the problem with `Clone`. */
+
+// Held for writing while loading or freeing a problem, and for reading during evaluations
+var aslLock sync.RWMutex
+
+/* Lock the problem for an evaluation, returning ErrClosed if it has been closed. Every successful
+   call must be followed by a call to `release` */
+func (p *Problem) acquire() error {
+	aslLock.RLock()
+	p.mu.Lock()
+	if p.asl == nil {
+		p.release()
+		return ErrClosed
+	}
+	return nil
+}
+
+/* Unlock the problem after an evaluation */
+func (p *Problem) release() {
+	p.mu.Unlock()
+	aslLock.RUnlock()
+}
+
+/* Load a separate copy of the problem by reading its `.nl` file again, which must still exist. Each copy has its
+   own AMPL state, so copies can be evaluated in parallel. The copy is read with the same options and scaled the same
+   way, but output suffixes set with `SetSuffix` aren't copied. The copy must be closed separately */
+func (p *Problem) Clone() (*Problem, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	name, path, opts := p.Name, p.path, p.options
+	p.release()
+	clone, err := LoadProblemWithOptions(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.copyScales(clone); err != nil {
+		clone.Close()
+		return nil, err
+	}
+	clone.Name = name
+	return clone, nil
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"sync"
+	"testing"
+)
+
+/* Evaluate clones of the nonlinear problem in parallel */
+func TestParallelClones(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	defer p.Close()
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			clone, err := p.Clone()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer clone.Close()
+			objs := clone.Objectives()
+			for i := 0; i < 200; i++ {
+				x := []float64{float64(g), float64(i%5) - 2}
+				val, err := objs[0].Value(x)
+				if err != nil {
+					errs <- err
+					return
+				}
+				expected := x[0]*x[0] + x[0]*x[1] + math.Exp(x[1])
+				if math.Abs(val-expected) > 1e-9 {
+					errs <- fmt.Errorf("Error: objective value %v at %v, expected %v", val, x, expected)
+					return
+				}
+				if _, err := clone.FullHessian(x, 0, nil, nil); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(err, "No error")
+	}
+}
+
+/* A single problem can be shared between goroutines */
+func TestSharedProblem(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	defer p.Close()
+	other := ProblemFromFile(testNonLinearModelFile)
+	defer other.Close()
+	var wg sync.WaitGroup
+	vals := make([][]float64, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				vals[g], _ = p.ConstraintValues([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0})
+				other.ConstraintValues([]float64{1, 2})
+			}
+		}(g)
+	}
+	wg.Wait()
+	for g := 0; g < 8; g++ {
+		assert.Equal(vals[g], []float64{370, 35, 24, 15, 10, 20, 20}, "Constraint values")
+	}
+}
+
+/* Cloning a closed problem fails */
+func TestCloneClosed(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	p.Close()
+	_, err := p.Clone()
+	assert.Equal(err, ErrClosed, "Error")
+}
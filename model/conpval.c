@@ -325,6 +325,8 @@ jacpval_ASL(ASL *a, real *X, real *G, fint *nerror)
 	k = n_conjac[1];
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, j, k-j);
+	if (ne0 >= 0 && *nerror)
+		return;
 	p0 = asl->P.cps;
 	for(; j < k; ++j) {
 		i = j;
@@ -502,6 +504,8 @@ objpgrd_ASL(ASL *a, int i, real *X, real *G, fint *nerror)
 		}
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, -(i+1), 1);
+	if (ne0 >= 0 && *nerror)
+		return;
 	Adjoints = adjoints;
 	p->nxval = asl->i.nxval;
 	if (p->ng)
@@ -684,6 +688,8 @@ conpgrd_ASL(ASL *a, int i, real *X, real *G, fint *nerror)
 		}
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, i, 1);
+	if (ne0 >= 0 && *nerror)
+		return;
 	Adjoints = adjoints;
 	p = asl->P.cps + i;
 	p->nxval = asl->i.nxval;
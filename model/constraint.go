@@ -2,34 +2,21 @@ package model
 
 import (
 	"strconv"
+
+	"github.com/alanctgardner/ampl-go/types"
 )
 
-type ConstraintSense int
+type ConstraintSense = types.ConstraintSense
 
 const (
-	ConstraintGreaterThan ConstraintSense = iota
-	ConstraintLessThan
-	ConstraintEqualTo
-	ConstraintRange
-	ConstraintNonBinding
+	ConstraintGreaterThan     = types.ConstraintGreaterThan
+	ConstraintLessThan        = types.ConstraintLessThan
+	ConstraintEqualTo         = types.ConstraintEqualTo
+	ConstraintRange           = types.ConstraintRange
+	ConstraintNonBinding      = types.ConstraintNonBinding
+	ConstraintComplementarity = types.ConstraintComplementarity
 )
 
-func (t ConstraintSense) String() string {
-	switch t {
-	case ConstraintGreaterThan:
-		return "Greater"
-	case ConstraintLessThan:
-		return "Less"
-	case ConstraintEqualTo:
-		return "Equals"
-	case ConstraintRange:
-		return "Range"
-	case ConstraintNonBinding:
-		return "Non-binding"
-	}
-	return "Unknown"
-}
-
 type Constraint struct {
 	Name      string
 	Sense     ConstraintSense
@@ -128,6 +128,8 @@ jac1val_ASL(ASL *a, real *X, real *G, fint *nerror)
 	k = n_conjac[1];
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, j, k-j);
+	if (ne0 >= 0 && *nerror)
+		goto done;
 	if (asl->i.zap_J)
 		memset(G, 0, asl->i.zap_J);
 	Adjoints = adjoints;
@@ -0,0 +1,34 @@
+g3 1 1 0	# problem defvar
+ 1 1 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 1 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 1 1 1	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 1 1	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 1 0 0 0 0	# common exprs: b,c,o,c1,o1
+V1 1 0	#t
+0 2
+o41	#sin
+v0	#x
+C0	#c
+o35	#if
+o22	#<
+v0	#x
+n1
+v1	#t
+n3
+O0 0	#f
+o2	#*
+v1	#t
+v1	#t
+r	#1 ranges (rhs's)
+1 5
+b	#1 bounds (on variables)
+0 -2 2
+k0	#intermediate Jacobian column lengths
+J0 1
+0 0
+G0 1
+0 0
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/alanctgardner/ampl-go/description"
+)
+
+/* Describe the structure of the problem: its variables, constraints and objectives with their names, bounds,
+   shapes and linear coefficients. The description can be written as JSON and read without cgo, see the
+   description package */
+func (p *Problem) Description() *description.Problem {
+	vars := p.Variables()
+	cons := p.Constraints()
+	objs := p.Objectives()
+	d := &description.Problem{
+		Version:     description.SchemaVersion,
+		Name:        p.exportProblemName(),
+		Variables:   make([]description.Variable, len(vars)),
+		Constraints: make([]description.Constraint, len(cons)),
+		Objectives:  make([]description.Objective, len(objs)),
+	}
+	for i, v := range vars {
+		d.Variables[i] = description.Variable{
+			Index:      v.Index,
+			Name:       v.Name,
+			Type:       description.DescribeVariableType(v.Type),
+			LowerBound: describeVariableBound(v.LowerBound),
+			UpperBound: describeVariableBound(v.UpperBound),
+		}
+	}
+	complemented := p.ComplementedVariables()
+	for i, c := range cons {
+		d.Constraints[i] = description.Constraint{
+			Index:  c.Index,
+			Name:   c.Name,
+			Sense:  description.DescribeConstraintSense(c.Sense),
+			Shape:  description.DescribeShape(c.Shape),
+			Min:    description.Bound(c.Min),
+			Max:    description.Bound(c.Max),
+			Linear: description.DescribeTerms(c.LinearTerms()),
+		}
+		if complemented[i] >= 0 {
+			v := complemented[i]
+			d.Constraints[i].ComplementedVariable = &v
+		}
+	}
+	for i, o := range objs {
+		d.Objectives[i] = description.Objective{
+			Index:    o.Index,
+			Name:     o.Name,
+			Sense:    description.DescribeObjectiveSense(o.Sense),
+			Shape:    description.DescribeShape(o.Shape),
+			Constant: o.Constant(),
+			Linear:   description.DescribeTerms(o.LinearTerms()),
+		}
+	}
+	return d
+}
+
+/* Marshal the problem's description as JSON */
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Description())
+}
+
+/* Write variable bounds clamped to +/-Plinfy as infinite */
+func describeVariableBound(x float64) description.Bound {
+	if isInfiniteVariableBound(x) {
+		return description.Bound(math.Inf(int(math.Copysign(1, x))))
+	}
+	return description.Bound(x)
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/alanctgardner/ampl-go/description"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+/* Describe the diet problem and read the JSON back */
+func TestDescription(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	data, err := json.Marshal(p)
+	assert.Nil(err, "Marshal")
+	var d description.Problem
+	assert.Nil(json.Unmarshal(data, &d), "Unmarshal")
+	assert.Equal(d, *p.Description(), "Round trip")
+
+	assert.Equal(d.Version, description.SchemaVersion, "Version")
+	assert.Equal(d.Name, "diet1", "Name")
+	assert.Equal(len(d.Variables), 9, "Variables")
+	assert.Equal(d.Variables[0], description.Variable{Index: 0, Name: "_svar[1]", Type: description.VariableInteger, LowerBound: 0, UpperBound: 11}, "Variable")
+	assert.Equal(len(d.Constraints), 7, "Constraints")
+	assert.Equal(d.Constraints[0].Sense, description.SenseGreaterThan, "Sense")
+	assert.True(math.IsInf(float64(d.Constraints[0].Max), 1), "Infinite bound")
+	assert.Equal(d.Constraints[1].Sense, description.SenseRange, "Range")
+	assert.Equal(len(d.Constraints[0].Linear), 9, "Linear terms")
+	assert.Nil(d.Constraints[0].ComplementedVariable, "Not complementarity")
+	assert.Equal(len(d.Objectives), 8, "Objectives")
+	assert.Equal(d.Objectives[0].Shape, description.ShapeLinear, "Shape")
+}
+
+/* Variables without bounds are described with infinite bounds */
+func TestDescriptionInfiniteBounds(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	d := p.Description()
+	assert.True(math.IsInf(float64(d.Variables[0].LowerBound), -1), "No lower bound")
+	assert.True(math.IsInf(float64(d.Variables[0].UpperBound), 1), "No upper bound")
+	assert.Equal(d.Objectives[0].Shape, description.ShapeNonLinear, "Shape")
+}
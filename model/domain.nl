@@ -0,0 +1,25 @@
+g3 1 1 0	# problem domain
+ 1 1 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 1 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 1 1 1	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 1 1	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+o43	#log
+v0	#x
+O0 0	#f
+o39	#sqrt
+v0	#x
+r	#1 ranges (rhs's)
+2 0
+b	#1 bounds (on variables)
+3
+k0	#intermediate Jacobian column lengths
+J0 1
+0 0
+G0 1
+0 0
@@ -186,6 +186,8 @@
  *	used for input more than STRTOD_DIGLIM digits long (default 40).
  */
 
+#include "arith.h"	/* for IEEE_8087 */
+
 #ifndef Long
 #define Long long
 #endif
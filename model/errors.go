@@ -0,0 +1,129 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/* Returned when evaluating a Problem after it has been closed */
+var ErrClosed = errors.New("Error: Problem has been closed")
+
+/* The reason AMPL was unable to read a `.nl` file. The values match the ASL_readerr codes */
+type ReadErrorKind int
+
+const (
+	ReadErrorNoFile ReadErrorKind = iota + 1
+	ReadErrorNonLinear
+	ReadErrorFunctionArguments
+	ReadErrorFunctionUnavailable
+	ReadErrorCorrupt
+	ReadErrorBug
+	ReadErrorLogicalConstraints
+	ReadErrorOutOfMemory
+)
+
+func (k ReadErrorKind) String() string {
+	switch k {
+	case ReadErrorNoFile:
+		return "cannot open file"
+	case ReadErrorNonLinear:
+		return "unexpected nonlinearities"
+	case ReadErrorFunctionArguments:
+		return "imported function with bad arguments"
+	case ReadErrorFunctionUnavailable:
+		return "imported function not available"
+	case ReadErrorCorrupt:
+		return "corrupt file"
+	case ReadErrorBug:
+		return "bug in the .nl reader"
+	case ReadErrorLogicalConstraints:
+		return "unsupported logical constraints"
+	case ReadErrorOutOfMemory:
+		return "out of memory"
+	}
+	return "unknown error"
+}
+
+/* Returned when AMPL is unable to read a `.nl` file */
+type ReadError struct {
+	/* The path of the file being read */
+	Path string
+
+	/* Why the file couldn't be read */
+	Kind ReadErrorKind
+
+	/* The line of the file where the error was found, or 0 if it isn't known */
+	Line int
+
+	/* The message printed by AMPL, if any */
+	Message string
+}
+
+func (e *ReadError) Error() string {
+	str := fmt.Sprintf("Error reading %q", e.Path)
+	if e.Line > 0 {
+		str += fmt.Sprintf(" at line %d", e.Line)
+	}
+	str += " - " + e.Kind.String()
+	if e.Message != "" {
+		str += ": " + e.Message
+	}
+	return str
+}
+
+/* Returned when AMPL can't evaluate the problem at a point, e.g. taking the log of a negative number */
+type EvalError struct {
+	/* The operator or imported function that failed, or "" if it isn't known */
+	Operator string
+
+	/* The arguments passed to the operator */
+	Args []float64
+
+	/* The index of the objective being evaluated, or -1 if the error was in a constraint */
+	Objective int
+
+	/* The index of the constraint being evaluated, or -1 if the error was in an objective */
+	Constraint int
+
+	/* The order of derivative being computed: 0 for values, 1 for gradients and 2 for Hessians */
+	Derivative int
+
+	/* The message returned by an imported function, if any */
+	Message string
+}
+
+func (e *EvalError) Error() string {
+	str := "Error evaluating "
+	if e.Constraint >= 0 {
+		str += fmt.Sprintf("constraint %d", e.Constraint)
+	} else if e.Objective >= 0 {
+		str += fmt.Sprintf("objective %d", e.Objective)
+	} else {
+		str += "problem"
+	}
+	if e.Operator == "" {
+		return str
+	}
+	switch e.Derivative {
+	case 0:
+		str += ": can't evaluate "
+	case 1:
+		str += ": can't compute the gradient of "
+	default:
+		str += ": can't compute the Hessian of "
+	}
+	switch {
+	case (e.Operator == "/" || e.Operator == "div") && len(e.Args) > 0:
+		str += fmt.Sprintf("%g %s 0", e.Args[0], e.Operator)
+	case e.Message != "":
+		str += fmt.Sprintf("%s: %s", e.Operator, e.Message)
+	default:
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = fmt.Sprintf("%g", a)
+		}
+		str += fmt.Sprintf("%s(%s)", e.Operator, strings.Join(args, ", "))
+	}
+	return str
+}
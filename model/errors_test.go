@@ -0,0 +1,148 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* Write `contents` to a temporary `.nl` file and try to load it */
+func loadTempProblem(t *testing.T, contents string) (*Problem, error) {
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bad.nl")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadProblem(path)
+}
+
+/* Loading a file that doesn't exist returns an error */
+func TestLoadMissingFile(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem("missing.nl")
+	assert.Nil(p, "No problem")
+	readErr, ok := err.(*ReadError)
+	assert.True(ok, "ReadError")
+	assert.Equal(readErr.Kind, ReadErrorNoFile, "Error kind")
+	assert.Equal(readErr.Path, "missing.nl", "Error path")
+}
+
+/* Loading a file with a bad header returns an error */
+func TestLoadBadHeader(t *testing.T) {
+	assert := assert.New(t)
+	_, err := loadTempProblem(t, "g3 1 1 0\n 9 7\n")
+	readErr, ok := err.(*ReadError)
+	assert.True(ok, "ReadError")
+	assert.Equal(readErr.Kind, ReadErrorCorrupt, "Error kind")
+	assert.Equal(readErr.Line, 2, "Error line")
+	assert.Contains(readErr.Message, "wanted", "Error message")
+}
+
+/* Loading a truncated file returns an error with the line number */
+func TestLoadTruncatedFile(t *testing.T) {
+	assert := assert.New(t)
+	contents, err := ioutil.ReadFile(testModelFile)
+	assert.Nil(err, "No error")
+	lines := strings.SplitAfter(string(contents), "\n")
+	_, err = loadTempProblem(t, strings.Join(lines[:60], ""))
+	readErr, ok := err.(*ReadError)
+	assert.True(ok, "ReadError")
+	assert.Equal(readErr.Kind, ReadErrorCorrupt, "Error kind")
+	assert.Equal(readErr.Line, 61, "Error line")
+	assert.Contains(readErr.Message, "Premature end of file", "Error message")
+}
+
+/* Loading a good file returns no error */
+func TestLoadProblem(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testModelFile)
+	assert.Nil(err, "No error")
+	assert.Equal(len(p.Variables()), 9, "Number of variables")
+}
+
+const testDomainModelFile = "domain.nl"
+
+/* Evaluating log(x) at a negative point returns an error naming the constraint and operator */
+func TestConstraintEvalError(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testDomainModelFile)
+	cons := p.Constraints()
+	_, err := cons[0].Value([]float64{-1})
+	evalErr, ok := err.(*EvalError)
+	assert.True(ok, "Error type")
+	assert.Equal(evalErr, &EvalError{Operator: "log", Args: []float64{-1}, Objective: -1, Constraint: 0, Derivative: 0}, "Error details")
+	assert.Equal(err.Error(), "Error evaluating constraint 0: can't evaluate log(-1)", "Error message")
+
+	// The problem is still usable after an error
+	val, err := cons[0].Value([]float64{1})
+	assert.Nil(err, "No error")
+	assert.Equal(val, float64(0), "Constraint value")
+}
+
+/* Evaluating sqrt(x) at a negative point returns an error naming the objective */
+func TestObjectiveEvalError(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testDomainModelFile)
+	objs := p.Objectives()
+	_, err := objs[0].Value([]float64{-4})
+	assert.Equal(err, &EvalError{Operator: "sqrt", Args: []float64{-4}, Objective: 0, Constraint: -1, Derivative: 0}, "Error details")
+	_, err = objs[0].Gradient([]float64{0})
+	assert.Equal(err.Error(), "Error evaluating objective 0: can't compute the gradient of sqrt(0)", "Error message")
+}
+
+/* Formatting a division error without its arguments falls back to the generic message */
+func TestEvalErrorWithoutArgs(t *testing.T) {
+	assert := assert.New(t)
+	err := &EvalError{Operator: "/", Objective: -1, Constraint: 2}
+	assert.Equal(err.Error(), "Error evaluating constraint 2: can't evaluate /()", "Error message")
+}
+
+/* Errors in the Hessian routines are returned instead of exiting */
+func TestHessianEvalError(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testDomainModelFile)
+	_, err := p.FullHessian([]float64{-1}, 0, nil, nil)
+	evalErr, ok := err.(*EvalError)
+	assert.True(ok, "Error type")
+	assert.Equal(evalErr.Operator, "sqrt", "Operator")
+	hes, err := p.FullHessian([]float64{4}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.InDelta(hes[0], -1.0/32, 1e-12, "Hessian value")
+}
+
+/* Closing a problem frees it, and later evaluations return ErrClosed */
+func TestCloseProblem(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	objs := p.Objectives()
+	assert.Nil(p.Close(), "No error")
+	assert.Nil(p.Close(), "Closing twice")
+	_, err := objs[0].Value([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0})
+	assert.Equal(err, ErrClosed, "Objective value")
+	_, err = p.ConstraintValues([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0})
+	assert.Equal(err, ErrClosed, "Constraint values")
+	_, err = p.FullHessian([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0}, 0, nil, nil)
+	assert.Equal(err, ErrClosed, "Hessian")
+	assert.Nil(p.Variables(), "No variables")
+	assert.Equal(p.JacobianNonZeros(), 0, "No Jacobian")
+}
+
+/* Closing one problem doesn't affect another, even if it was loaded later */
+func TestCloseOtherProblem(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	defer p.Close()
+	other := ProblemFromFile(testModelFile)
+	assert.Nil(other.Close(), "No error")
+	hes, err := p.FullHessian([]float64{1, 2}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{2, 1, 1, math.Exp(2)}, "Hessian values")
+}
@@ -1334,7 +1334,7 @@ aholread(EdRead *R)
 			fprintf(Stderr,
 				 "Premature end of file in aholread, line %ld of %s\n",
 					R->Line, R->asl->i.filename_);
-				exit_ASL(R,1);
+				exit_ASL(R,ASL_readerr_corrupt);
 			}
 		if (k == '\n') {
 			R->Line++;
@@ -0,0 +1,359 @@
+package model
+
+/*
+#include "asl_pfgh.h"
+
+// Helper functions to call the Hessian function pointers
+typedef fint (sphsetFunc) (ASL* asl, SputInfo **spi, int nobj, int ow, int y, int uptri);
+typedef void (sphesFunc) (ASL* asl, SputInfo **spi, real *H, int nobj, real *ow, real *y);
+typedef void (xknownFunc) (ASL* asl, real *X, fint *nerror);
+typedef void (hvinitFunc) (ASL* asl, int hid_limit, int nobj, real *ow, real *y);
+typedef void (hvcompFunc) (ASL* asl, real *hv, real *p, int nobj, real *ow, real *y);
+typedef void (fulhesFunc) (ASL* asl, real *H, fint LH, int nobj, real *ow, real *y);
+typedef void (duthesFunc) (ASL* asl, real *H, int nobj, real *ow, real *y);
+
+// The Hessian routines evaluate the problem functions without asking for errors to be returned,
+// so catch evaluation errors with err_jmp2 and return the error code instead of exiting
+#define CATCH_EVAL_ERR(asl, jb) \
+	cur_ASL = asl; \
+	asl->i.evalerr_.who = 0; \
+	asl->i.err_jmp2_ = &jb; \
+	if (__builtin_setjmp(jb.jb)) { \
+		asl->i.err_jmp2_ = 0; \
+		return jb.err; \
+	}
+
+fint callSphsetFunc(void *f, ASL *asl, int nobj, int ow, int y, int uptri) {
+	cur_ASL = asl;
+	return ((sphsetFunc*)f)(asl, 0, nobj, ow, y, uptri);
+}
+
+fint callSphesFunc(void *f, ASL *asl, real *H, int nobj, real *ow, real *y) {
+	Jmp_buf jb;
+	CATCH_EVAL_ERR(asl, jb)
+	((sphesFunc*)f)(asl, 0, H, nobj, ow, y);
+	asl->i.err_jmp2_ = 0;
+	return 0;
+}
+
+void callXknownFunc(void *f, ASL *asl, real *X, fint *nerror) {
+	cur_ASL = asl;
+	asl->i.evalerr_.who = 0;
+	((xknownFunc*)f)(asl, X, nerror);
+}
+
+// hvinit caches second derivative information, which needs the gradients at x to be computed first
+fint callHvinitFunc(void *f, ASL *asl, int hid_limit, int nobj, real *ow, real *y) {
+	Jmp_buf jb;
+	CATCH_EVAL_ERR(asl, jb)
+	xpsg_check_ASL((ASL_pfgh*)asl, nobj, ow, y);
+	((hvinitFunc*)f)(asl, hid_limit, nobj, ow, y);
+	asl->i.err_jmp2_ = 0;
+	return 0;
+}
+
+fint callHvcompFunc(void *f, ASL *asl, real *hv, real *p, int nobj, real *ow, real *y) {
+	Jmp_buf jb;
+	CATCH_EVAL_ERR(asl, jb)
+	((hvcompFunc*)f)(asl, hv, p, nobj, ow, y);
+	asl->i.err_jmp2_ = 0;
+	return 0;
+}
+
+fint callFulhesFunc(void *f, ASL *asl, real *H, fint LH, int nobj, real *ow, real *y) {
+	Jmp_buf jb;
+	CATCH_EVAL_ERR(asl, jb)
+	((fulhesFunc*)f)(asl, H, LH, nobj, ow, y);
+	asl->i.err_jmp2_ = 0;
+	return 0;
+}
+
+fint callDuthesFunc(void *f, ASL *asl, real *H, int nobj, real *ow, real *y) {
+	Jmp_buf jb;
+	CATCH_EVAL_ERR(asl, jb)
+	((duthesFunc*)f)(asl, H, nobj, ow, y);
+	asl->i.err_jmp2_ = 0;
+	return 0;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+/* The Hessian of the Lagrangian computed by AMPL is
+       sum(objWeights[i] * Hessian(objective i)) + sum(multipliers[j] * Hessian(constraint j))
+   Only the upper triangle is stored, since the matrix is symmetric */
+
+/* Set up the sparse Hessian of the Lagrangian. If `objective` is a valid index only that objective is included,
+   weighted by `objWeights[objective]` (or 1) when evaluating. If `objective` is -1 every objective is included
+   when `weighted` is true, and none are otherwise. Any other index is an error. The constraints are only included
+   if `multipliers` is true. Returns the row and column of each nonzero in the upper triangle, in the order
+   `HessianValues` fills its buffer */
+func (p *Problem) HessianStructure(objective int, weighted bool, multipliers bool) ([]int, []int, error) {
+	if err := p.acquire(); err != nil {
+		return nil, nil, err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	if err := p.checkObjective(objective); err != nil {
+		return nil, nil, err
+	}
+	numNonZeros := int(C.callSphsetFunc(unsafe.Pointer(p.asl.p.Sphset), p.asl, C.int(objective), cBool(weighted), cBool(multipliers), 1))
+	p.hessian = &hessianSetup{objective, weighted, multipliers, numNonZeros}
+
+	spi := p.asl.i.sputinfo_
+	colStarts := (*[1 << 30]C.fint)(unsafe.Pointer(spi.hcolstarts))[: numVariables+1 : numVariables+1]
+	rowNums := (*[1 << 30]C.fint)(unsafe.Pointer(spi.hrownos))[:numNonZeros:numNonZeros]
+	rows := make([]int, numNonZeros)
+	cols := make([]int, numNonZeros)
+	for j := 0; j < numVariables; j++ {
+		for k := int(colStarts[j]); k < int(colStarts[j+1]); k++ {
+			rows[k] = int(rowNums[k])
+			cols[k] = j
+		}
+	}
+	return rows, cols, nil
+}
+
+/* Evaluate the nonzeros of the Hessian of the Lagrangian at point x into `hes`, using the structure from the last
+   call to `HessianStructure`. `objWeights` has one entry per objective and may be nil when a single objective
+   was chosen. `multipliers` has one entry per constraint and must be nil if the constraints were left out */
+func (p *Problem) HessianValues(x []float64, objWeights []float64, multipliers []float64, hes []float64) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	if p.hessian == nil {
+		return fmt.Errorf("Error: HessianStructure must be called before HessianValues")
+	}
+	ow, y, err := p.lagrangianWeights(p.hessian.objective, p.hessian.weighted, p.hessian.multipliers, objWeights, multipliers)
+	if err != nil {
+		return err
+	}
+	if len(hes) != p.hessian.numNonZeros {
+		return fmt.Errorf("Error: Incorrect size of Hessian buffer: expected %d, got %d", p.hessian.numNonZeros, len(hes))
+	}
+	if err := p.setPoint(x); err != nil {
+		return err
+	}
+	defer p.clearPoint()
+	if len(hes) == 0 {
+		return nil
+	}
+	if code := C.callSphesFunc(unsafe.Pointer(p.asl.p.Sphes), p.asl, (*C.real)(unsafe.Pointer(&hes[0])), C.int(p.hessian.objective), ow, y); code != 0 {
+		return p.evalError(code)
+	}
+	return nil
+}
+
+/* Compute the product of the Hessian of the Lagrangian at point x with `direction`, without forming the Hessian.
+   If `objective` is a valid index only that objective is included, weighted by `objWeights[objective]` (or 1).
+   If `objective` is -1 every objective is included, weighted by `objWeights`, unless `objWeights` is nil.
+   Any other index is an error. The constraints are included, weighted by `multipliers`, unless `multipliers` is nil */
+func (p *Problem) HessianVectorProduct(x []float64, direction []float64, objective int, objWeights []float64, multipliers []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	if len(direction) != numVariables {
+		return nil, fmt.Errorf("Error: Incorrect size of direction: expected %d, got %d", numVariables, len(direction))
+	}
+	if err := p.checkObjective(objective); err != nil {
+		return nil, err
+	}
+	ow, y, err := p.lagrangianWeights(objective, objWeights != nil, multipliers != nil, objWeights, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.setPoint(x); err != nil {
+		return nil, err
+	}
+	defer p.clearPoint()
+	hv := make([]float64, numVariables)
+	if code := C.callHvinitFunc(unsafe.Pointer(p.asl.p.Hvinit), p.asl, p.asl.p.ihd_limit_, C.int(objective), ow, y); code != 0 {
+		return nil, p.evalError(code)
+	}
+	if code := C.callHvcompFunc(unsafe.Pointer(p.asl.p.Hvcomp), p.asl, (*C.real)(unsafe.Pointer(&hv[0])), (*C.real)(unsafe.Pointer(&direction[0])), C.int(objective), ow, y); code != 0 {
+		return nil, p.evalError(code)
+	}
+	return hv, nil
+}
+
+/* Compute the dense Hessian of the Lagrangian at point x. The objective weights and multipliers are used as in
+   `HessianVectorProduct`. The result has one row per variable, with entry (i, j) at index i*n+j */
+func (p *Problem) FullHessian(x []float64, objective int, objWeights []float64, multipliers []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	if err := p.checkObjective(objective); err != nil {
+		return nil, err
+	}
+	ow, y, err := p.lagrangianWeights(objective, objWeights != nil, multipliers != nil, objWeights, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.setPoint(x); err != nil {
+		return nil, err
+	}
+	defer p.clearPoint()
+	hes := make([]float64, numVariables*numVariables)
+	if code := C.callFulhesFunc(unsafe.Pointer(p.asl.p.Fulhes), p.asl, (*C.real)(unsafe.Pointer(&hes[0])), C.fint(numVariables), C.int(objective), ow, y); code != 0 {
+		return nil, p.evalError(code)
+	}
+	return hes, nil
+}
+
+/* Compute the upper triangle of the dense Hessian of the Lagrangian at point x. The objective weights and
+   multipliers are used as in `HessianVectorProduct`. The triangle is packed by columns, with entry (i, j) for
+   i <= j at index j*(j+1)/2+i */
+func (p *Problem) UpperTriangleHessian(x []float64, objective int, objWeights []float64, multipliers []float64) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	if err := p.checkObjective(objective); err != nil {
+		return nil, err
+	}
+	ow, y, err := p.lagrangianWeights(objective, objWeights != nil, multipliers != nil, objWeights, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.setPoint(x); err != nil {
+		return nil, err
+	}
+	defer p.clearPoint()
+	hes := make([]float64, numVariables*(numVariables+1)/2)
+	if code := C.callDuthesFunc(unsafe.Pointer(p.asl.p.Duthes), p.asl, (*C.real)(unsafe.Pointer(&hes[0])), C.int(objective), ow, y); code != 0 {
+		return nil, p.evalError(code)
+	}
+	return hes, nil
+}
+
+/* The arguments passed to sphsetup, which sphes must be called with */
+type hessianSetup struct {
+	objective   int
+	weighted    bool
+	multipliers bool
+	numNonZeros int
+}
+
+/* Check the objective index passed to the Hessian routines, where -1 means no single objective */
+func (p *Problem) checkObjective(objective int) error {
+	numObjectives := int(p.asl.i.n_obj_)
+	if objective < -1 || objective >= numObjectives {
+		return fmt.Errorf("Error: Objective index %d out of range", objective)
+	}
+	return nil
+}
+
+/* Check the objective weights and multipliers for a Hessian computation and convert them to the pointers AMPL expects */
+func (p *Problem) lagrangianWeights(objective int, weighted bool, useMultipliers bool, objWeights []float64, multipliers []float64) (*C.real, *C.real, error) {
+	numObjectives := int(p.asl.i.n_obj_)
+	numConstraints := int(p.asl.i.n_con_)
+	var ow, y *C.real
+	if objWeights != nil {
+		if len(objWeights) != numObjectives {
+			return nil, nil, fmt.Errorf("Error: Incorrect number of objective weights: expected %d, got %d", numObjectives, len(objWeights))
+		}
+		if numObjectives > 0 {
+			ow = (*C.real)(unsafe.Pointer(&objWeights[0]))
+		}
+	} else if objective < 0 && weighted {
+		return nil, nil, fmt.Errorf("Error: Objective weights are required when no single objective is chosen")
+	}
+	if objective < 0 && !weighted {
+		ow = nil
+	}
+	if multipliers != nil {
+		if !useMultipliers {
+			return nil, nil, fmt.Errorf("Error: Multipliers given for a Hessian without constraints")
+		}
+		if len(multipliers) != numConstraints {
+			return nil, nil, fmt.Errorf("Error: Incorrect number of multipliers: expected %d, got %d", numConstraints, len(multipliers))
+		}
+		if numConstraints > 0 {
+			y = (*C.real)(unsafe.Pointer(&multipliers[0]))
+		}
+	} else if useMultipliers {
+		return nil, nil, fmt.Errorf("Error: Multipliers are required for a Hessian with constraints")
+	}
+	return ow, y, nil
+}
+
+/* Tell AMPL that subsequent second derivative computations are at point x */
+func (p *Problem) setPoint(x []float64) error {
+	numVariables := int(p.asl.i.n_var_)
+	if len(x) != numVariables {
+		return fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", numVariables, len(x))
+	}
+	var err C.fint
+	C.callXknownFunc(unsafe.Pointer(p.asl.p.Xknown), p.asl, (*C.real)(unsafe.Pointer(&x[0])), &err)
+	if err != 0 {
+		return p.evalError(err)
+	}
+	return nil
+}
+
+/* Undo `setPoint` so later evaluations check their input point again */
+func (p *Problem) clearPoint() {
+	p.asl.i.x_known = 0
+}
+
+/* Convert a bool to the int flags AMPL uses */
+func cBool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+/* Compute the full, symmetric sparse Hessian of a single objective, or of the constraints weighted by `multipliers`
+   if `objective` is -1, at point x. Entries that are zero at x are left out. The structure set up by the last call to
+   `HessianStructure` is restored afterwards */
+func (p *Problem) sparseHessian(x []float64, objective int, multipliers []float64) (*SparseMatrix, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	ow, y, err := p.lagrangianWeights(objective, false, multipliers != nil, nil, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	numNonZeros := int(C.callSphsetFunc(unsafe.Pointer(p.asl.p.Sphset), p.asl, C.int(objective), 0, cBool(multipliers != nil), 0))
+	if p.hessian != nil {
+		defer C.callSphsetFunc(unsafe.Pointer(p.asl.p.Sphset), p.asl, C.int(p.hessian.objective), cBool(p.hessian.weighted), cBool(p.hessian.multipliers), 1)
+	}
+	spi := p.asl.i.sputinfo_
+	colStarts := (*[1 << 30]C.fint)(unsafe.Pointer(spi.hcolstarts))[: numVariables+1 : numVariables+1]
+	rowNums := (*[1 << 30]C.fint)(unsafe.Pointer(spi.hrownos))[:numNonZeros:numNonZeros]
+	hes := make([]float64, numNonZeros)
+	if numNonZeros > 0 {
+		if err := p.setPoint(x); err != nil {
+			return nil, err
+		}
+		defer p.clearPoint()
+		if code := C.callSphesFunc(unsafe.Pointer(p.asl.p.Sphes), p.asl, (*C.real)(unsafe.Pointer(&hes[0])), C.int(objective), ow, y); code != 0 {
+			return nil, p.evalError(code)
+		}
+	}
+
+	m := &SparseMatrix{NumRows: numVariables, NumCols: numVariables, ColumnMajor: true, Start: make([]int, numVariables+1)}
+	for j := 0; j < numVariables; j++ {
+		for k := int(colStarts[j]); k < int(colStarts[j+1]); k++ {
+			if hes[k] != 0 {
+				m.Index = append(m.Index, int(rowNums[k]))
+				m.Values = append(m.Values, hes[k])
+			}
+		}
+		m.Start[j+1] = len(m.Index)
+	}
+	return m, nil
+}
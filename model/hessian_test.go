@@ -0,0 +1,106 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+const testNonLinearModelFile = "hs1.nl"
+
+/* The diet problem is linear, so its Hessian is empty */
+func TestDietHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	rows, cols, err := p.HessianStructure(0, false, true)
+	assert.Nil(err, "No error")
+	assert.Equal(len(rows), 0, "Hessian rows")
+	assert.Equal(len(cols), 0, "Hessian columns")
+	err = p.HessianValues([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0}, nil, make([]float64, 7), []float64{})
+	assert.Nil(err, "No error")
+}
+
+/* Get the Hessian of the objective in the nonlinear problem */
+func TestNonLinearObjectiveHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	rows, cols, err := p.HessianStructure(0, false, false)
+	assert.Nil(err, "No error")
+	assert.Equal(rows, []int{0, 0, 1}, "Hessian rows")
+	assert.Equal(cols, []int{0, 1, 1}, "Hessian columns")
+	hes := make([]float64, len(rows))
+	err = p.HessianValues([]float64{1, 2}, nil, nil, hes)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{2, 1, math.Exp(2)}, "Hessian values")
+}
+
+/* Get the Hessian of the Lagrangian in the nonlinear problem */
+func TestNonLinearLagrangianHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	rows, _, err := p.HessianStructure(-1, true, true)
+	assert.Nil(err, "No error")
+	hes := make([]float64, len(rows))
+	err = p.HessianValues([]float64{1, 2}, []float64{2}, []float64{0.5, 3}, hes)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{5, 2, 2*math.Exp(2) + 1}, "Hessian values")
+	err = p.HessianValues([]float64{1, 2}, nil, []float64{0.5, 3}, hes)
+	assert.NotNil(err, "Missing objective weights")
+	_, _, err = p.HessianStructure(1, false, true)
+	assert.EqualError(err, "Error: Objective index 1 out of range", "Invalid objective")
+	_, _, err = p.HessianStructure(-2, false, true)
+	assert.EqualError(err, "Error: Objective index -2 out of range", "Invalid objective")
+}
+
+/* Multiply the Hessian of the Lagrangian in the nonlinear problem with a direction */
+func TestNonLinearHessianVectorProduct(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	hv, err := p.HessianVectorProduct([]float64{1, 2}, []float64{1, -1}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.InDeltaSlice(hv, []float64{1, 1 - math.Exp(2)}, 1e-12, "Objective Hessian-vector product")
+	hv, err = p.HessianVectorProduct([]float64{1, 2}, []float64{1, -1}, -1, []float64{2}, []float64{0.5, 3})
+	assert.Nil(err, "No error")
+	assert.InDeltaSlice(hv, []float64{3, 1 - 2*math.Exp(2)}, 1e-12, "Lagrangian Hessian-vector product")
+	_, err = p.HessianVectorProduct([]float64{1, 2}, []float64{1}, 0, nil, nil)
+	assert.NotNil(err, "Wrong direction size")
+	_, err = p.HessianVectorProduct([]float64{1, 2}, []float64{1, -1}, 1, nil, nil)
+	assert.EqualError(err, "Error: Objective index 1 out of range", "Invalid objective")
+}
+
+/* Get the dense Hessian of the Lagrangian in the nonlinear problem */
+func TestNonLinearFullHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	hes, err := p.FullHessian([]float64{1, 2}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{2, 1, 1, math.Exp(2)}, "Objective Hessian")
+	hes, err = p.FullHessian([]float64{1, 2}, -1, []float64{2}, []float64{0.5, 3})
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{5, 2, 2, 2*math.Exp(2) + 1}, "Lagrangian Hessian")
+	_, err = p.FullHessian([]float64{1, 2}, 2, nil, nil)
+	assert.EqualError(err, "Error: Objective index 2 out of range", "Invalid objective")
+}
+
+/* Get the dense upper triangle of the Hessian of the Lagrangian in the nonlinear problem */
+func TestNonLinearUpperTriangleHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	hes, err := p.UpperTriangleHessian([]float64{1, 2}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{2, 1, math.Exp(2)}, "Objective Hessian")
+	hes, err = p.UpperTriangleHessian([]float64{1, 2}, -1, nil, []float64{0.5, 3})
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{1, 0, 1}, "Constraint Hessian")
+	_, err = p.UpperTriangleHessian([]float64{1, 2}, -3, nil, nil)
+	assert.EqualError(err, "Error: Objective index -3 out of range", "Invalid objective")
+}
+
+/* The dense Hessian of the diet problem is all zeros */
+func TestDietFullHessian(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	hes, err := p.FullHessian([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.Equal(hes, make([]float64, 81), "Objective Hessian")
+}
@@ -0,0 +1,29 @@
+MINOS 5.51: optimal solution found.
+3 iterations, objective 0.8347
+
+Options
+3
+1
+1
+0
+2
+2
+2
+2
+0
+0.5
+-0.5
+0.75
+objno 1 0
+suffix 0 2 8 0 0
+sstatus
+0 1
+1 3
+suffix 5 1 6 0 0
+slack
+1 0.25
+suffix 3 1 7 31 2
+status
+0	good	all is well
+1	bad
+0 1
@@ -0,0 +1,56 @@
+g3 1 1 0	# problem indicator_ne
+ 3 1 1 0 0 2	# vars, constraints, objectives, ranges, eqns, lcons
+ 0 0	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 0 0 0	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 1 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 2 3	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+n0
+L0	#l1
+o72	#==>
+o24	#==
+v2	#b
+n1
+o30	#!=
+o0	#+
+v0	#x
+o2	#*
+n2
+v1	#y
+n8
+n1
+L1	#l2
+o72	#==>
+o24	#==
+v2	#b
+n0
+o28	#>=
+o1	#-
+v0	#x
+v1	#y
+n1
+o24	#==
+v0	#x
+n3
+O0 0	#f
+n0
+r	#1 ranges (rhs's)
+1 15
+b	#3 bounds (on variables)
+0 0 10
+0 0 10
+0 0 1
+k2	#intermediate Jacobian column lengths
+1
+2
+J0 2
+0 1
+1 1
+G0 3
+0 1
+1 1
+2 1
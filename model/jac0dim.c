@@ -81,7 +81,7 @@ badfmt(EdRead *R)
 {
 	badread(R);
 	fprintf(Stderr, "Unrecognized binary format.\n");
-	exit(1);
+	exit_ASL(R, ASL_readerr_corrupt);
 	}
 
  static void
@@ -93,7 +93,7 @@ badints(EdRead *R, int got, int wanted)
 {
 	badread(R);
 	fprintf(Stderr, "got only %d integers; wanted %d\n", got, wanted);
-	exit(1);
+	exit_ASL(R, ASL_readerr_corrupt);
 	}
 
  static void
@@ -173,7 +173,7 @@ jac0dim_ASL(ASL *asl, char *stub, ftnlen stub_len)
 					fprintf(Stderr,
 					 "expected 6th integer to be 0 or 6, not %d\n",
 						ncsi);
-					exit(1);
+					exit_ASL(R, ASL_readerr_corrupt);
 					}
 				s = read_line(R);
 				k = Sscanf(s, " %d %d %d %d %d %d",
@@ -206,7 +206,7 @@ jac0dim_ASL(ASL *asl, char *stub, ftnlen stub_len)
 				if (k > 9) {
 					fprintf(Stderr,
 					"ampl_options = %d is too large\n", k);
-					exit(1);
+					exit_ASL(R, ASL_readerr_corrupt);
 					}
 				for(i = 1; i <= k && se > s; i++)
 					ampl_options[i] = strtol(s = se,&se,10);
@@ -284,7 +284,7 @@ jac0dim_ASL(ASL *asl, char *stub, ftnlen stub_len)
 		what_prog();
 		fprintf(Stderr,
 		"jacdim: got M = %d, N = %d, NO = %d\n", n_con, n_var, n_obj);
-		exit(1);
+		exit_ASL(R, ASL_readerr_corrupt);
 		}
 	asl->i.opfmt = opfmt;
 	asl->i.n_var0 = asl->i.n_var1 = n_var;
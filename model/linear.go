@@ -0,0 +1,210 @@
+package model
+
+/*
+#include "asl_pfgh.h"
+
+// The constant term of objective i. pfgh_read leaves a linear objective's constant as its whole expression,
+// and otherwise adds it to the first nonlinear term. Sets *nonlinear if the objective has nonlinear terms
+real objConstant(ASL_pfgh *asl, int i, int *nonlinear) {
+	ps_func *f = asl->P.ops + i;
+	expr2 *e;
+
+	if (!(*nonlinear = f->nb + f->ng > 0))
+		return ((expr_n*)asl->I.obj2_de_[i].e)->v;
+	if (!f->nb)
+		return 0.;
+	e = f->b->D.e;
+	if (e->op == (efunc2*)f_OPNUM_ASL)
+		return ((expr_n*)e)->v;
+	// r_ops[0] is OPPLUS
+	if (e->op == r2_ops_ASL[0] && e->R.e->op == (efunc2*)f_OPNUM_ASL)
+		return ((expr_n*)e->R.e)->v;
+	return 0.;
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+/* A variable and its coefficient in the linear part of a constraint or objective */
+type LinearTerm = types.LinearTerm
+
+/* A sparse matrix in compressed form. In CSC form `Start` has an entry per column and `Index` holds row numbers;
+   in CSR form `Start` has an entry per row and `Index` holds column numbers. The entries of row or column i are
+   `Index[Start[i]:Start[i+1]]` and `Values[Start[i]:Start[i+1]]` */
+type SparseMatrix struct {
+	NumRows     int
+	NumCols     int
+	ColumnMajor bool
+	Start       []int
+	Index       []int
+	Values      []float64
+}
+
+/* Get the linear terms of the constraint, in scaled space if it or its variables are scaled. Variables that only
+   appear nonlinearly have a coefficient of 0 */
+func (c Constraint) LinearTerms() []LinearTerm {
+	return c.p.linearTerms(c.Index, false)
+}
+
+/* Get the linear terms of the objective, in scaled space if its variables are scaled. Variables that only appear
+   nonlinearly have a coefficient of 0 */
+func (o Objective) LinearTerms() []LinearTerm {
+	return o.p.linearTerms(o.Index, true)
+}
+
+func (p *Problem) linearTerms(index int, objective bool) []LinearTerm {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	vars := p.variables()
+	terms := make([]LinearTerm, 0)
+	if objective {
+		numObjectives := int(p.asl.i.n_obj_)
+		ogradList := (*[1 << 30]*C.struct_ograd)(unsafe.Pointer(p.asl.i.Ograd_))[:numObjectives:numObjectives]
+		for gradPtr := ogradList[index]; gradPtr != nil; gradPtr = gradPtr.next {
+			coef := float64(gradPtr.coef) * p.variableScale(int(gradPtr.varno))
+			terms = append(terms, LinearTerm{Variable: vars[gradPtr.varno], Coefficient: coef})
+		}
+	} else {
+		numConstraints := int(p.asl.i.n_con_)
+		cgradList := (*[1 << 30]*C.struct_cgrad)(unsafe.Pointer(p.asl.i.Cgrad_))[:numConstraints:numConstraints]
+		scale := p.constraintScale(index)
+		for gradPtr := cgradList[index]; gradPtr != nil; gradPtr = gradPtr.next {
+			coef := float64(gradPtr.coef) * scale * p.variableScale(int(gradPtr.varno))
+			terms = append(terms, LinearTerm{Variable: vars[gradPtr.varno], Coefficient: coef})
+		}
+	}
+	return terms
+}
+
+/* Get the coefficients of the linear part of the constraints as a matrix in compressed sparse column form,
+   with a row per constraint and a column per variable, in scaled space if the problem is scaled. The matrix has an entry for each nonzero of the
+   Jacobian, including variables that only appear nonlinearly */
+func (p *Problem) LinearMatrixCSC() *SparseMatrix {
+	return p.linearMatrix(true)
+}
+
+/* Get the coefficients of the linear part of the constraints as a matrix in compressed sparse row form,
+   with a row per constraint and a column per variable, in scaled space if the problem is scaled. The matrix has an entry for each nonzero of the
+   Jacobian, including variables that only appear nonlinearly */
+func (p *Problem) LinearMatrixCSR() *SparseMatrix {
+	return p.linearMatrix(false)
+}
+
+func (p *Problem) linearMatrix(columnMajor bool) *SparseMatrix {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	numConstraints := int(p.asl.i.n_con_)
+	numVariables := int(p.asl.i.n_var_)
+	numNonZeros := int(p.asl.i.nzc_)
+	m := &SparseMatrix{
+		NumRows:     numConstraints,
+		NumCols:     numVariables,
+		ColumnMajor: columnMajor,
+		Index:       make([]int, numNonZeros),
+		Values:      make([]float64, numNonZeros),
+	}
+	numMajor := numConstraints
+	if columnMajor {
+		numMajor = numVariables
+	}
+	m.Start = make([]int, numMajor+1)
+	if numConstraints == 0 {
+		return m
+	}
+	cgradList := (*[1 << 30]*C.struct_cgrad)(unsafe.Pointer(p.asl.i.Cgrad_))[:numConstraints:numConstraints]
+
+	/* Count the entries in each row or column, then fill them in order */
+	for i := 0; i < numConstraints; i++ {
+		for gradPtr := cgradList[i]; gradPtr != nil; gradPtr = gradPtr.next {
+			if columnMajor {
+				m.Start[gradPtr.varno+1]++
+			} else {
+				m.Start[i+1]++
+			}
+		}
+	}
+	for i := 0; i < numMajor; i++ {
+		m.Start[i+1] += m.Start[i]
+	}
+	next := make([]int, numMajor)
+	copy(next, m.Start)
+	for i := 0; i < numConstraints; i++ {
+		for gradPtr := cgradList[i]; gradPtr != nil; gradPtr = gradPtr.next {
+			major, minor := i, int(gradPtr.varno)
+			if columnMajor {
+				major, minor = minor, major
+			}
+			m.Index[next[major]] = minor
+			m.Values[next[major]] = float64(gradPtr.coef) * p.constraintScale(i) * p.variableScale(int(gradPtr.varno))
+			next[major]++
+		}
+	}
+	return m
+}
+
+/* An objective split into a constant, a linear part and a nonlinear remainder, so that
+       Value(x) = Constant + sum(Linear[i].Coefficient * x[Linear[i].Variable.Index]) + remainder(x) */
+type AffineDecomposition struct {
+	Constant float64
+
+	/* The linear terms, as returned by `LinearTerms` */
+	Linear []LinearTerm
+
+	/* Whether the nonlinear remainder is identically zero, i.e. the objective is affine */
+	NonLinearIsZero bool
+
+	o Objective
+}
+
+/* Get the constant term of the objective, like AMPL's objconst */
+func (o Objective) Constant() float64 {
+	constant, _ := o.p.objConstant(o.Index)
+	return constant
+}
+
+/* Split the objective into its constant, linear part and nonlinear remainder */
+func (o Objective) Decompose() *AffineDecomposition {
+	constant, nonLinear := o.p.objConstant(o.Index)
+	return &AffineDecomposition{
+		Constant:        constant,
+		Linear:          o.LinearTerms(),
+		NonLinearIsZero: !nonLinear,
+		o:               o,
+	}
+}
+
+/* Compute the nonlinear remainder of the objective at the given point */
+func (d *AffineDecomposition) NonLinearValue(x []float64) (float64, error) {
+	if d.NonLinearIsZero {
+		return 0, nil
+	}
+	val, err := d.o.Value(x)
+	if err != nil {
+		return 0, err
+	}
+	val -= d.Constant
+	for _, term := range d.Linear {
+		val -= term.Coefficient * x[term.Variable.Index]
+	}
+	return val, nil
+}
+
+/* Get the constant term of an objective and whether it has nonlinear terms */
+func (p *Problem) objConstant(index int) (float64, bool) {
+	if p.acquire() != nil {
+		return 0, false
+	}
+	defer p.release()
+	var nonLinear C.int
+	constant := C.objConstant(p.aslPfgh, C.int(index), &nonLinear)
+	return float64(constant), nonLinear != 0
+}
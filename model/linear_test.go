@@ -0,0 +1,73 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+/* Get the coefficients of a linear constraint and objective in the diet problem */
+func TestDietLinearTerms(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	vars := p.Variables()
+	con := p.Constraints()[4]
+	assert.Equal(con.LinearTerms(), []LinearTerm{{Variable: vars[0], Coefficient: 6}, {Variable: vars[1], Coefficient: 10}, {Variable: vars[2], Coefficient: 2}, {Variable: vars[4], Coefficient: 15}, {Variable: vars[5], Coefficient: 15}, {Variable: vars[7], Coefficient: 4}, {Variable: vars[8], Coefficient: 120}}, "Constraint terms")
+	obj := p.Objectives()[0]
+	assert.Equal(obj.LinearTerms(), []LinearTerm{{Variable: vars[0], Coefficient: 1.84}, {Variable: vars[1], Coefficient: 2.19}, {Variable: vars[2], Coefficient: 1.84}, {Variable: vars[3], Coefficient: 1.44}, {Variable: vars[4], Coefficient: 2.29}, {Variable: vars[5], Coefficient: 0.77}, {Variable: vars[6], Coefficient: 1.29}, {Variable: vars[7], Coefficient: 0.6}, {Variable: vars[8], Coefficient: 0.72}}, "Objective terms")
+}
+
+/* Variables that only appear nonlinearly have a coefficient of 0 */
+func TestNonLinearLinearTerms(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	vars := p.Variables()
+	cons := p.Constraints()
+	assert.Equal(cons[0].LinearTerms(), []LinearTerm{{Variable: vars[0], Coefficient: 0}, {Variable: vars[1], Coefficient: 0}}, "Nonlinear constraint")
+	assert.Equal(cons[1].LinearTerms(), []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[1], Coefficient: 2}}, "Linear constraint")
+}
+
+/* Get the constraint matrix in both compressed forms */
+func TestLinearMatrix(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Equal(p.LinearMatrixCSC(), &SparseMatrix{2, 2, true, []int{0, 2, 4}, []int{0, 1, 0, 1}, []float64{0, 1, 0, 2}}, "CSC")
+	assert.Equal(p.LinearMatrixCSR(), &SparseMatrix{2, 2, false, []int{0, 2, 4}, []int{0, 1, 0, 1}, []float64{0, 0, 1, 2}}, "CSR")
+
+	p = ProblemFromFile(testModelFile)
+	csc := p.LinearMatrixCSC()
+	csr := p.LinearMatrixCSR()
+	assert.Equal(csc.Start[len(csc.Start)-1], p.JacobianNonZeros(), "CSC nonzeros")
+	assert.Equal(csr.Start[len(csr.Start)-1], p.JacobianNonZeros(), "CSR nonzeros")
+	assert.Equal(csr.Index[csr.Start[4]:csr.Start[5]], []int{0, 1, 2, 4, 5, 7, 8}, "Row 4 columns")
+	assert.Equal(csr.Values[csr.Start[4]:csr.Start[5]], []float64{6, 10, 2, 15, 15, 4, 120}, "Row 4 values")
+	assert.Equal(csc.Index[csc.Start[3]:csc.Start[4]], []int{0, 1, 2, 3, 5, 6}, "Column 3 rows")
+}
+
+/* Split quadratic and affine objectives into their constant, linear and nonlinear parts */
+func TestDecompose(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testQuadraticModelFile)
+	vars := p.Variables()
+	objs := p.Objectives()
+	d := objs[0].Decompose()
+	assert.Equal(d.Constant, 1.0, "Constant")
+	assert.Equal(d.Linear, []LinearTerm{{Variable: vars[0], Coefficient: 0}, {Variable: vars[1], Coefficient: 0}, {Variable: vars[2], Coefficient: 3}}, "Linear terms")
+	assert.False(d.NonLinearIsZero, "Nonlinear objective")
+	val, err := d.NonLinearValue([]float64{1, 2, 3})
+	assert.Nil(err, "No error")
+	assert.Equal(val, 3.0, "Nonlinear remainder")
+
+	d = objs[1].Decompose()
+	assert.Equal(objs[1].Constant(), 5.0, "Constant")
+	assert.Equal(d.Constant, 5.0, "Constant")
+	assert.Equal(d.Linear, []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[1], Coefficient: -1}}, "Linear terms")
+	assert.True(d.NonLinearIsZero, "Affine objective")
+	val, err = objs[1].Value([]float64{1, 2, 3})
+	assert.Nil(err, "No error")
+	assert.Equal(val, 4.0, "Value includes the constant")
+
+	p = ProblemFromFile(testNonLinearModelFile)
+	assert.Equal(p.Objectives()[0].Constant(), 0.0, "No constant")
+	p = ProblemFromFile(testModelFile)
+	assert.True(p.Objectives()[0].Decompose().NonLinearIsZero, "Linear objective")
+}
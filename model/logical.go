@@ -0,0 +1,299 @@
+package model
+
+/*
+#include "asl_pfgh.h"
+
+// The vendored ASL doesn't include indcons.c, so indicator_constrs_ASL isn't available and the logical
+// constraints are walked here instead. Op codes are the indices into r_op.hd
+
+// Get the op code of e, or -1 if it isn't known. The reader converts most ops to function pointers but
+// can leave numbers with their op code
+int exprOpcode(expr2 *e) {
+	int k;
+
+	if (e->op == (efunc2*)f_OPNUM_ASL)
+		return 79;
+	for(k = 0; k < 82; k++)
+		if (r2_ops_ASL[k] && e->op == r2_ops_ASL[k])
+			return k;
+	if ((size_t)e->op < 82)
+		return (int)(size_t)e->op;
+	return -1;
+}
+
+// Get the index of the variable e refers to, or -1 if e is a defined variable
+int exprVariable(ASL_pfgh *asl, expr2 *e) {
+	expr2_v *v = (expr2_v*)e;
+
+	if (v >= asl->I.var2_e_ && v < asl->I.var2_e_ + asl->i.n_var_)
+		return (int)(v - asl->I.var2_e_);
+	return -1;
+}
+
+real exprNumber(expr2 *e) {
+	return ((expr_n*)e)->v;
+}
+
+expr2 *exprLeft(expr2 *e) {
+	return e->L.e;
+}
+
+expr2 *exprRight(expr2 *e) {
+	return e->R.e;
+}
+
+// The arguments of a list op such as OPSUMLIST or ANDLIST run from L.ep to R.ep
+int exprListLen(expr2 *e) {
+	return (int)(e->R.ep - e->L.ep);
+}
+
+expr2 *exprListItem(expr2 *e, int i) {
+	return e->L.ep[i];
+}
+
+expr2 *exprIfCond(expr2 *e) {
+	return ((expr2_if*)e)->e;
+}
+
+expr2 *exprIfThen(expr2 *e) {
+	return ((expr2_if*)e)->T;
+}
+
+expr2 *exprIfElse(expr2 *e) {
+	return ((expr2_if*)e)->F;
+}
+
+expr2 *logicalConstraint(ASL_pfgh *asl, int i) {
+	return asl->I.lcon2_de_[i].e;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sort"
+)
+
+/* Op codes used in logical constraints */
+const (
+	opPlus     = 0
+	opMinus    = 1
+	opMult     = 2
+	opDiv      = 3
+	opUMinus   = 16
+	opAnd      = 21
+	opLT       = 22
+	opLE       = 23
+	opEQ       = 24
+	opGE       = 28
+	opGT       = 29
+	opNE       = 30
+	opSumList  = 54
+	opAndList  = 70
+	opImpElse  = 72
+	opNumber   = 79
+	opVariable = 81
+)
+
+/* An indicator constraint: when the binary variable takes the trigger value, the linear constraint
+       sum(Terms[i].Coefficient * x[Terms[i].Variable.Index]) Sense RHS
+   must hold */
+type IndicatorConstraint struct {
+	/* The index of the logical constraint this came from. A logical constraint like `b = 1 ==> c1 else c2`
+	   gives an indicator constraint for each branch */
+	LogicalIndex int
+
+	/* The binary variable and the value, 0 or 1, that triggers the constraint */
+	Variable Variable
+	Value    int
+
+	Terms []LinearTerm
+
+	/* ConstraintLessThan, ConstraintGreaterThan or ConstraintEqualTo */
+	Sense ConstraintSense
+	RHS   float64
+}
+
+/* Get the number of logical constraints in the problem. They are only read when the problem is loaded with
+   `LogicalConstraints` */
+func (p *Problem) NumLogicalConstraints() int {
+	if p.acquire() != nil {
+		return 0
+	}
+	defer p.release()
+	return int(p.asl.i.n_lcon_)
+}
+
+/* Get the indicator constraints, like AMPL's indicator_constrs. Logical constraints must be implications
+   `b = v ==> constraint`, optionally with an `else constraint`, or conjunctions of them, where b is a binary
+   variable, v is 0 or 1 and the constraints are linear comparisons with <=, >= or =. Strict inequalities and
+   != can't be written as indicator constraints without changing the feasible set. Returns an error naming the first logical constraint
+   that doesn't have this form */
+func (p *Problem) IndicatorConstraints() ([]IndicatorConstraint, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	vars := p.variables()
+	indicators := make([]IndicatorConstraint, 0)
+	for i := 0; i < int(p.asl.i.n_lcon_); i++ {
+		var err error
+		indicators, err = p.indicators(vars, i, C.logicalConstraint(p.aslPfgh, C.int(i)), indicators)
+		if err != nil {
+			name := C.GoString(C.lcon_name_ASL(p.asl, C.int(i)))
+			return nil, fmt.Errorf("Error: Logical constraint %s is not an indicator constraint: %v", name, err)
+		}
+	}
+	return indicators, nil
+}
+
+/* Add the indicator constraints in logical constraint expression e to indicators */
+func (p *Problem) indicators(vars []Variable, index int, e *C.expr2, indicators []IndicatorConstraint) ([]IndicatorConstraint, error) {
+	var err error
+	switch C.exprOpcode(e) {
+	case opAnd:
+		if indicators, err = p.indicators(vars, index, C.exprLeft(e), indicators); err != nil {
+			return nil, err
+		}
+		return p.indicators(vars, index, C.exprRight(e), indicators)
+	case opAndList:
+		for i := 0; i < int(C.exprListLen(e)); i++ {
+			if indicators, err = p.indicators(vars, index, C.exprListItem(e, C.int(i)), indicators); err != nil {
+				return nil, err
+			}
+		}
+		return indicators, nil
+	case opImpElse:
+		v, value, err := p.indicatorCondition(vars, C.exprIfCond(e))
+		if err != nil {
+			return nil, err
+		}
+		ind, err := p.indicator(vars, C.exprIfThen(e))
+		if err != nil {
+			return nil, err
+		}
+		ind.LogicalIndex, ind.Variable, ind.Value = index, v, value
+		indicators = append(indicators, *ind)
+
+		/* Without an else branch the implication has `else 1`, which is always true */
+		elseExpr := C.exprIfElse(e)
+		if C.exprOpcode(elseExpr) == opNumber && C.exprNumber(elseExpr) != 0 {
+			return indicators, nil
+		}
+		if ind, err = p.indicator(vars, elseExpr); err != nil {
+			return nil, err
+		}
+		ind.LogicalIndex, ind.Variable, ind.Value = index, v, 1-value
+		return append(indicators, *ind), nil
+	}
+	return nil, fmt.Errorf("unsupported expression")
+}
+
+/* Get the binary variable and its value from the condition `b = v` of an implication */
+func (p *Problem) indicatorCondition(vars []Variable, e *C.expr2) (Variable, int, error) {
+	if C.exprOpcode(e) != opEQ {
+		return Variable{}, 0, fmt.Errorf("the condition isn't an equality")
+	}
+	left, right := C.exprLeft(e), C.exprRight(e)
+	if C.exprOpcode(left) == opNumber {
+		left, right = right, left
+	}
+	if C.exprOpcode(left) != opVariable || C.exprOpcode(right) != opNumber {
+		return Variable{}, 0, fmt.Errorf("the condition doesn't compare a variable to a value")
+	}
+	index := int(C.exprVariable(p.aslPfgh, left))
+	if index < 0 || vars[index].Type != VariableBinary {
+		return Variable{}, 0, fmt.Errorf("the condition's variable isn't binary")
+	}
+	value := float64(C.exprNumber(right))
+	if value != 0 && value != 1 {
+		return Variable{}, 0, fmt.Errorf("the condition's value %v isn't 0 or 1", value)
+	}
+	return vars[index], int(value), nil
+}
+
+/* Get the linear constraint implied by a branch of an implication. The variable and value are filled in
+   by the caller */
+func (p *Problem) indicator(vars []Variable, e *C.expr2) (*IndicatorConstraint, error) {
+	var sense ConstraintSense
+	switch C.exprOpcode(e) {
+	case opLE:
+		sense = ConstraintLessThan
+	case opGE:
+		sense = ConstraintGreaterThan
+	case opEQ:
+		sense = ConstraintEqualTo
+	case opLT, opGT:
+		return nil, fmt.Errorf("the implied constraint is a strict inequality")
+	case opNE:
+		return nil, fmt.Errorf("the implied constraint is a != comparison")
+	default:
+		return nil, fmt.Errorf("the implied constraint isn't a comparison")
+	}
+
+	/* Move everything to the left hand side: left - right sense 0 */
+	coefs := make(map[int]float64)
+	constant := 0.0
+	if err := p.linearize(C.exprLeft(e), 1, coefs, &constant); err != nil {
+		return nil, err
+	}
+	if err := p.linearize(C.exprRight(e), -1, coefs, &constant); err != nil {
+		return nil, err
+	}
+	ind := &IndicatorConstraint{Terms: make([]LinearTerm, 0, len(coefs)), Sense: sense, RHS: -constant}
+	for index, coef := range coefs {
+		if coef != 0 {
+			ind.Terms = append(ind.Terms, LinearTerm{Variable: vars[index], Coefficient: coef})
+		}
+	}
+	sort.Slice(ind.Terms, func(i, j int) bool { return ind.Terms[i].Variable.Index < ind.Terms[j].Variable.Index })
+	return ind, nil
+}
+
+/* Add scale * e to the coefficients and constant. Returns an error if e isn't linear */
+func (p *Problem) linearize(e *C.expr2, scale float64, coefs map[int]float64, constant *float64) error {
+	switch C.exprOpcode(e) {
+	case opNumber:
+		*constant += scale * float64(C.exprNumber(e))
+		return nil
+	case opVariable:
+		index := int(C.exprVariable(p.aslPfgh, e))
+		if index < 0 {
+			return fmt.Errorf("the implied constraint uses a defined variable")
+		}
+		coefs[index] += scale
+		return nil
+	case opPlus:
+		if err := p.linearize(C.exprLeft(e), scale, coefs, constant); err != nil {
+			return err
+		}
+		return p.linearize(C.exprRight(e), scale, coefs, constant)
+	case opMinus:
+		if err := p.linearize(C.exprLeft(e), scale, coefs, constant); err != nil {
+			return err
+		}
+		return p.linearize(C.exprRight(e), -scale, coefs, constant)
+	case opUMinus:
+		return p.linearize(C.exprLeft(e), -scale, coefs, constant)
+	case opSumList:
+		for i := 0; i < int(C.exprListLen(e)); i++ {
+			if err := p.linearize(C.exprListItem(e, C.int(i)), scale, coefs, constant); err != nil {
+				return err
+			}
+		}
+		return nil
+	case opMult:
+		left, right := C.exprLeft(e), C.exprRight(e)
+		if C.exprOpcode(right) == opNumber {
+			left, right = right, left
+		}
+		if C.exprOpcode(left) == opNumber {
+			return p.linearize(right, scale*float64(C.exprNumber(left)), coefs, constant)
+		}
+	case opDiv:
+		if right := C.exprRight(e); C.exprOpcode(right) == opNumber && C.exprNumber(right) != 0 {
+			return p.linearize(C.exprLeft(e), scale/float64(C.exprNumber(right)), coefs, constant)
+		}
+	}
+	return fmt.Errorf("the implied constraint isn't linear")
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testIndicatorModelFile = "indicator.nl"
+
+/* Logical constraints are only read when they're asked for */
+func TestLogicalConstraintsOption(t *testing.T) {
+	assert := assert.New(t)
+	_, err := LoadProblem(testIndicatorModelFile)
+	assert.Error(err, "Logical constraints not allowed")
+
+	p, err := LoadProblemWithOptions(testIndicatorModelFile, LoadOptions{LogicalConstraints: true})
+	assert.Nil(err, "Logical constraints allowed")
+	assert.Equal(p.NumLogicalConstraints(), 2, "Logical constraints")
+	assert.Equal(len(p.Constraints()), 1, "Algebraic constraints")
+}
+
+/* List the indicator constraints, including the one implied by an else branch */
+func TestIndicatorConstraints(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblemWithOptions(testIndicatorModelFile, LoadOptions{LogicalConstraints: true})
+	assert.Nil(err, "Load problem")
+	vars := p.Variables()
+	inds, err := p.IndicatorConstraints()
+	assert.Nil(err, "Indicator constraints")
+	assert.Equal(inds, []IndicatorConstraint{
+		{0, vars[2], 1, []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[1], Coefficient: 2}}, ConstraintLessThan, 8},
+		{1, vars[2], 0, []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[1], Coefficient: -1}}, ConstraintGreaterThan, 1},
+		{1, vars[2], 1, []LinearTerm{{Variable: vars[0], Coefficient: 1}}, ConstraintEqualTo, 3},
+	}, "Indicator constraints")
+
+	for file, message := range map[string]string{
+		"indicator_strict.nl": "the implied constraint is a strict inequality",
+		"indicator_ne.nl":     "the implied constraint is a != comparison",
+	} {
+		p, err = LoadProblemWithOptions(file, LoadOptions{LogicalConstraints: true})
+		assert.Nil(err, "Load problem")
+		_, err = p.IndicatorConstraints()
+		assert.EqualError(err, "Error: Logical constraint _slogcon[1] is not an indicator constraint: "+message, file)
+	}
+
+	p = ProblemFromFile(testModelFile)
+	inds, err = p.IndicatorConstraints()
+	assert.Nil(err, "No logical constraints")
+	assert.Empty(inds, "No indicator constraints")
+}
@@ -0,0 +1,246 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/* The longest line written to an LP file before terms wrap onto the next line. CPLEX reads lines up to 560
+   characters */
+const lpLineLength = 255
+
+/* Write the problem in CPLEX LP format, for linear, integer and quadratic problems. Only the first objective
+   is written. Ranges are written as `name: min <= expression <= max`, and constraints without bounds as
+   `expression >= -infinity`.
+
+   The names are the ones AMPL wrote to the `.col` and `.row` files, as for `WriteMPS`. LP files don't allow
+   square brackets, which are replaced with parentheses, and other characters the format reserves are replaced
+   with underscores. Returns an error if the problem has nonlinear constraints, complementarity constraints or
+   logical constraints */
+func (p *Problem) WriteLP(w io.Writer) error {
+	obj, f, err := p.exportObjective()
+	if err != nil {
+		return err
+	}
+	vars := p.Variables()
+	cons := p.Constraints()
+	if err := checkExportConstraints(cons, true); err != nil {
+		return err
+	}
+	if p.NumLogicalConstraints() > 0 {
+		return fmt.Errorf("Error: Logical constraints can't be exported")
+	}
+	names := make([]string, len(vars))
+	for i, v := range vars {
+		names[i] = lpName(v.Name)
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "\\ Problem name: %s\n", p.exportProblemName())
+	if obj.Sense == ObjectiveMax {
+		fmt.Fprintf(b, "Maximize\n")
+	} else {
+		fmt.Fprintf(b, "Minimize\n")
+	}
+	tokens := append([]string{lpName(obj.Name) + ":"}, lpExpression(names, f, true)...)
+	if f.Constant != 0 {
+		tokens = append(tokens, lpSigned(f.Constant, ""))
+	}
+	writeLPLine(b, tokens)
+
+	fmt.Fprintf(b, "Subject To\n")
+	m := p.LinearMatrixCSR()
+	for _, c := range cons {
+		g := &QuadraticFunction{Linear: make([]LinearTerm, 0, m.Start[c.Index+1]-m.Start[c.Index])}
+		if c.Shape == Quadratic {
+			if g, err = c.Quadratic(); err != nil {
+				return err
+			}
+		} else {
+			for k := m.Start[c.Index]; k < m.Start[c.Index+1]; k++ {
+				g.Linear = append(g.Linear, LinearTerm{Variable: vars[m.Index[k]], Coefficient: m.Values[k]})
+			}
+		}
+		expr := lpExpression(names, g, false)
+		if len(expr) == 0 {
+			expr = []string{"0"}
+		}
+		tokens := []string{lpName(c.Name) + ":"}
+		switch c.Sense {
+		case ConstraintGreaterThan:
+			tokens = append(append(tokens, expr...), ">=", formatNumber(c.Min-g.Constant))
+		case ConstraintLessThan:
+			tokens = append(append(tokens, expr...), "<=", formatNumber(c.Max-g.Constant))
+		case ConstraintEqualTo:
+			tokens = append(append(tokens, expr...), "=", formatNumber(c.Min-g.Constant))
+		case ConstraintRange:
+			if c.Shape == Quadratic {
+				// Quadratic constraints can't be ranges in an LP file, so write a row for each side
+				lower := append([]string{lpName(c.Name+"_lo") + ":"}, expr...)
+				writeLPLine(b, append(lower, ">=", formatNumber(c.Min-g.Constant)))
+				upper := append([]string{lpName(c.Name+"_hi") + ":"}, expr...)
+				tokens = append(upper, "<=", formatNumber(c.Max-g.Constant))
+				break
+			}
+			tokens = append(tokens, formatNumber(c.Min-g.Constant), "<=")
+			tokens = append(append(tokens, expr...), "<=", formatNumber(c.Max-g.Constant))
+		default:
+			tokens = append(append(tokens, expr...), ">=", "-infinity")
+		}
+		writeLPLine(b, tokens)
+	}
+
+	fmt.Fprintf(b, "Bounds\n")
+	for i, v := range vars {
+		if v.Type != VariableBinary {
+			if bound := lpBound(names[i], v); bound != "" {
+				fmt.Fprintf(b, " %s\n", bound)
+			}
+		}
+	}
+	for _, section := range []struct {
+		name string
+		t    VariableType
+	}{{"General", VariableInteger}, {"Binary", VariableBinary}} {
+		sectionNames := make([]string, 0)
+		for i, v := range vars {
+			if v.Type == section.t {
+				sectionNames = append(sectionNames, names[i])
+			}
+		}
+		if len(sectionNames) > 0 {
+			fmt.Fprintf(b, "%s\n", section.name)
+			writeLPLine(b, sectionNames)
+		}
+	}
+	fmt.Fprintf(b, "End\n")
+	return b.Flush()
+}
+
+/* Get the terms of a linear or quadratic function without its constant. The quadratic terms of an objective
+   are written as [ x'Qx ] / 2 and those of a constraint as [ 0.5 * x'Qx ] */
+func lpExpression(names []string, f *QuadraticFunction, objective bool) []string {
+	tokens := make([]string, 0, len(f.Linear))
+	for _, term := range f.Linear {
+		if term.Coefficient != 0 {
+			tokens = append(tokens, lpSigned(term.Coefficient, names[term.Variable.Index]))
+		}
+	}
+	quad := make([]string, 0)
+	if f.Q != nil {
+		for j := 0; j < f.Q.NumCols; j++ {
+			for k := f.Q.Start[j]; k < f.Q.Start[j+1]; k++ {
+				i, coef := f.Q.Index[k], f.Q.Values[k]
+				if i > j || coef == 0 {
+					continue
+				}
+				/* Q is symmetric, so the off-diagonal terms appear twice in x'Qx */
+				if i < j {
+					coef *= 2
+				}
+				if !objective {
+					coef /= 2
+				}
+				if i == j {
+					quad = append(quad, lpSigned(coef, names[i]+" ^ 2"))
+				} else {
+					quad = append(quad, lpSigned(coef, names[i]+" * "+names[j]))
+				}
+			}
+		}
+	}
+	if len(quad) > 0 {
+		quad[0] = strings.TrimPrefix(quad[0], "+ ")
+		tokens = append(tokens, "+ [")
+		tokens = append(tokens, quad...)
+		if objective {
+			tokens = append(tokens, "] / 2")
+		} else {
+			tokens = append(tokens, "]")
+		}
+	}
+	if len(tokens) > 0 {
+		tokens[0] = strings.TrimPrefix(tokens[0], "+ ")
+	}
+	return tokens
+}
+
+/* Format a term with its sign, like "+ 2 x" or "- x". An empty name gives a constant */
+func lpSigned(coef float64, name string) string {
+	sign := "+ "
+	if coef < 0 {
+		sign, coef = "- ", -coef
+	}
+	if name == "" {
+		return sign + formatNumber(coef)
+	}
+	if coef == 1 {
+		return sign + name
+	}
+	return sign + formatNumber(coef) + " " + name
+}
+
+/* Write the tokens on an indented line, wrapping before lines get too long */
+func writeLPLine(b *bufio.Writer, tokens []string) {
+	length := 0
+	for _, token := range tokens {
+		if length > 0 && length+len(token)+1 > lpLineLength {
+			fmt.Fprintf(b, "\n")
+			length = 0
+		}
+		fmt.Fprintf(b, " %s", token)
+		length += len(token) + 1
+	}
+	fmt.Fprintf(b, "\n")
+}
+
+/* Get the Bounds entry for a variable, or an empty string for the default bounds [0, inf) */
+func lpBound(name string, v Variable) string {
+	lowerIsInf := isInfiniteVariableBound(v.LowerBound)
+	upperIsInf := isInfiniteVariableBound(v.UpperBound)
+	switch {
+	case v.LowerBound == v.UpperBound:
+		return name + " = " + formatNumber(v.LowerBound)
+	case lowerIsInf && upperIsInf:
+		return name + " free"
+	case lowerIsInf:
+		return "-infinity <= " + name + " <= " + formatNumber(v.UpperBound)
+	case upperIsInf && v.LowerBound != 0:
+		return name + " >= " + formatNumber(v.LowerBound)
+	case !upperIsInf:
+		return formatNumber(v.LowerBound) + " <= " + name + " <= " + formatNumber(v.UpperBound)
+	}
+	return ""
+}
+
+/* Make a name valid in an LP file. Names can't contain square brackets, which hold the quadratic terms, or
+   operators, and can't start with a digit, a period, or an e followed by a digit or another e, which would
+   be read as a number */
+func lpName(name string) string {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case r == '[':
+			return '('
+		case r == ']':
+			return ')'
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case strings.ContainsRune("!\"#$%&()/,.;?@_`'{}|~", r):
+			return r
+		}
+		return '_'
+	}, name)
+	if mapped == "" {
+		return "_"
+	}
+	first := mapped[0]
+	if (first >= '0' && first <= '9') || first == '.' {
+		return "_" + mapped
+	}
+	if (first == 'e' || first == 'E') && len(mapped) > 1 && strings.ContainsRune("0123456789eE", rune(mapped[1])) {
+		return "_" + mapped
+	}
+	return mapped
+}
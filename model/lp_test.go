@@ -0,0 +1,72 @@
+package model
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+/* Write a mixed-integer problem with a quadratic objective and a range constraint */
+func TestWriteLP(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testMIQPModelFile)
+	var buf bytes.Buffer
+	assert.Nil(p.WriteLP(&buf), "Write LP")
+	assert.Equal(buf.String(), `\ Problem name: miqp
+Maximize
+ profit: 2 x + z + 3 y + [ - 2 x ^ 2 ] / 2 + 4
+Subject To
+ c1: x + z + y <= 10
+ c2: 1 <= x - y <= 5
+Bounds
+ -5 <= y <= 20
+General
+ y
+Binary
+ z
+End
+`, "LP file")
+}
+
+/* Write quadratic constraints, moving their constants to the right hand side */
+func TestWriteLPQuadraticConstraint(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testQuadraticModelFile)
+	var buf bytes.Buffer
+	assert.Nil(p.WriteLP(&buf), "Write LP")
+	assert.Equal(buf.String(), `\ Problem name: qp
+Minimize
+ _sobj(1): 3 _svar(3) + [ 2 _svar(1) ^ 2 + 2 _svar(1) * _svar(2) ] / 2 + 1
+Subject To
+ _scon(1): _svar(1) - 2 _svar(2) + [ _svar(1) * _svar(2) + _svar(2) ^ 2 ] <= 3
+ _scon(2): _svar(1) + 2 _svar(3) >= 1
+Bounds
+ _svar(1) free
+ _svar(2) free
+ _svar(3) free
+End
+`, "LP file")
+}
+
+/* Quadratic range constraints are written as two one-sided rows */
+func TestWriteLPQuadraticRange(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile("qp_range.nl")
+	var buf bytes.Buffer
+	assert.Nil(p.WriteLP(&buf), "Write LP")
+	assert.Contains(buf.String(), `Subject To
+ _scon(1)_lo: _svar(1) - 2 _svar(2) + [ _svar(1) * _svar(2) + _svar(2) ^ 2 ] >= -3
+ _scon(1)_hi: _svar(1) - 2 _svar(2) + [ _svar(1) * _svar(2) + _svar(2) ^ 2 ] <= 3
+ _scon(2): _svar(1) + 2 _svar(3) >= 1
+Bounds
+`, "LP file")
+}
+
+/* Names are changed to ones LP files allow */
+func TestLPName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(lpName("x[1,'a b']"), "x(1,'a_b')", "Brackets and spaces")
+	assert.Equal(lpName("1x"), "_1x", "Leading digit")
+	assert.Equal(lpName("e1"), "_e1", "Exponent")
+	assert.Equal(lpName("each"), "each", "Leading e")
+}
@@ -0,0 +1,43 @@
+g3 1 1 0	# problem miqp
+ 3 2 1 1 0	# vars, constraints, objectives, ranges, eqns
+ 0 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 0 1 0	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 1 1 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 5 3	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+n0
+C1	#c2
+n0
+O0 1	#profit
+o0	#+
+o2	#*
+n-1
+o5	#^
+v0	#x
+n2
+n4
+r	#2 ranges (rhs's)
+1 10
+0 1 5
+b	#3 bounds (on variables)
+2 0
+0 0 1
+0 -5 20
+k2	#intermediate Jacobian column lengths
+2
+3
+J0 3
+0 1
+1 1
+2 1
+J1 2
+0 1
+2 -1
+G0 3
+0 2
+1 1
+2 3
@@ -37,7 +37,7 @@ extern "C" {
 
 real edagread_one = 1.;
 char *progname;
-ASL *cur_ASL;
+ASL_THREAD_LOCAL ASL *cur_ASL;
 ASLhead ASLhead_ASL = {&ASLhead_ASL, &ASLhead_ASL};
 
  static char anyedag[] = "fg_read (or one of its variants)";
@@ -59,8 +59,11 @@ get_cur_ASL(void)
 exit_ASL(EdRead *R, int n)
 {
 	Jmp_buf *J;
-	if ((J = R->asl->i.err_jmp_) && n > 0)
+	if ((J = R->asl->i.err_jmp_) && n > 0) {
+		J->err = n;
+		J->line = R->Line;
 		__builtin_longjmp(J->jb, 1);
+		}
 	exit(n);
 	}
 
@@ -353,7 +356,7 @@ read_line(EdRead *R)
 				fprintf(Stderr,
 				 "Premature end of file, line %ld of %s\n",
 					R->Line, R->asl->i.filename_);
-				exit_ASL(R,1);
+				exit_ASL(R,ASL_readerr_corrupt);
 				}
 			if (x == '\n')
 				break;
@@ -385,9 +388,15 @@ read_line(EdRead *R)
  static void
 memfailure(const char *who, const char *what, size_t len)
 {
+	Jmp_buf *J;
 	if (progname)
 		fprintf(Stderr, "%s: ", progname);
 	fprintf(Stderr, "%s(%lu) failure: %s.\n", who, (long)len, what);
+	if (cur_ASL && (J = cur_ASL->i.err_jmp_)) {
+		J->err = ASL_readerr_nomem;
+		J->line = 0;
+		__builtin_longjmp(J->jb, 1);
+		}
 	exit(1);
 	}
 
@@ -462,7 +471,7 @@ badline(EdRead *R)
 		}
 	else
 		fprintf(Stderr, "\n");
-	exit_ASL(R,1);
+	exit_ASL(R,ASL_readerr_corrupt);
 	}
 
 #undef asl
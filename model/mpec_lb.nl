@@ -0,0 +1,33 @@
+g3 1 1 0	# problem mpec_lb
+ 2 2 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 0 0 1 0 0 1	# nonlinear constraints, objectives; ccons: lin, nonlin, nd, nzlb
+ 0 0	# network constraints: nonlinear, linear
+ 0 0 0	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 4 2	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+n1
+C1	#c2
+n0
+O0 0	#f
+n0
+r	#2 ranges (rhs's)
+5 1 2
+2 1
+b	#2 bounds (on variables)
+2 0
+2 1
+k1	#intermediate Jacobian column lengths
+2
+J0 2
+0 1
+1 -1
+J1 2
+0 1
+1 1
+G0 2
+0 1
+1 1
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/* Write the problem in free-format MPS, for linear and mixed-integer problems. A quadratic objective is written
+   in a QUADOBJ section, with the entries of the upper triangle of Q for 0.5 * x'Qx. Only the first objective is
+   written, and its constant is written as the negated right hand side of the objective row.
+
+   The names are the ones AMPL wrote to the `.col` and `.row` files next to the `.nl` file (`option auxfiles rc`),
+   or generic names like `_svar[1]` without them. Whitespace in names is replaced with underscores.
+   Returns an error if the problem has nonlinear or quadratic constraints, complementarity constraints or logical
+   constraints */
+func (p *Problem) WriteMPS(w io.Writer) error {
+	obj, f, err := p.exportObjective()
+	if err != nil {
+		return err
+	}
+	vars := p.Variables()
+	cons := p.Constraints()
+	if err := checkExportConstraints(cons, false); err != nil {
+		return err
+	}
+	if p.NumLogicalConstraints() > 0 {
+		return fmt.Errorf("Error: Logical constraints can't be exported")
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "NAME %s\n", exportName(p.exportProblemName()))
+	if obj.Sense == ObjectiveMax {
+		fmt.Fprintf(b, "OBJSENSE\n    MAX\n")
+	}
+
+	objName := exportName(obj.Name)
+	fmt.Fprintf(b, "ROWS\n N %s\n", objName)
+	for _, c := range cons {
+		fmt.Fprintf(b, " %s %s\n", mpsRowType(c.Sense), exportName(c.Name))
+	}
+
+	/* The objective coefficient comes first in each column, then the constraint coefficients */
+	objCoefs := make([]float64, len(vars))
+	for _, term := range f.Linear {
+		objCoefs[term.Variable.Index] = term.Coefficient
+	}
+	m := p.LinearMatrixCSC()
+	fmt.Fprintf(b, "COLUMNS\n")
+	integer := false
+	for j, v := range vars {
+		isInteger := v.Type == VariableInteger || v.Type == VariableBinary
+		if isInteger && !integer {
+			fmt.Fprintf(b, "    MARKER 'MARKER' 'INTORG'\n")
+		} else if !isInteger && integer {
+			fmt.Fprintf(b, "    MARKER 'MARKER' 'INTEND'\n")
+		}
+		integer = isInteger
+		name := exportName(v.Name)
+		written := false
+		if objCoefs[j] != 0 {
+			fmt.Fprintf(b, "    %s %s %s\n", name, objName, formatNumber(objCoefs[j]))
+			written = true
+		}
+		for k := m.Start[j]; k < m.Start[j+1]; k++ {
+			if m.Values[k] != 0 {
+				fmt.Fprintf(b, "    %s %s %s\n", name, exportName(cons[m.Index[k]].Name), formatNumber(m.Values[k]))
+				written = true
+			}
+		}
+		// Every column has to be declared here before it's used in BOUNDS or QUADOBJ
+		if !written {
+			fmt.Fprintf(b, "    %s %s 0\n", name, objName)
+		}
+	}
+	if integer {
+		fmt.Fprintf(b, "    MARKER 'MARKER' 'INTEND'\n")
+	}
+
+	fmt.Fprintf(b, "RHS\n")
+	if f.Constant != 0 {
+		fmt.Fprintf(b, "    RHS %s %s\n", objName, formatNumber(-f.Constant))
+	}
+	for _, c := range cons {
+		rhs := c.Min
+		if c.Sense == ConstraintLessThan || c.Sense == ConstraintRange {
+			rhs = c.Max
+		}
+		if c.Sense != ConstraintNonBinding && rhs != 0 {
+			fmt.Fprintf(b, "    RHS %s %s\n", exportName(c.Name), formatNumber(rhs))
+		}
+	}
+
+	/* Ranges are written as L rows, so the row lies in [rhs - R, rhs] */
+	ranges := false
+	for _, c := range cons {
+		if c.Sense == ConstraintRange {
+			if !ranges {
+				fmt.Fprintf(b, "RANGES\n")
+				ranges = true
+			}
+			fmt.Fprintf(b, "    RNG %s %s\n", exportName(c.Name), formatNumber(c.Max-c.Min))
+		}
+	}
+
+	bounds := false
+	for _, v := range vars {
+		for _, bound := range mpsBounds(v) {
+			if !bounds {
+				fmt.Fprintf(b, "BOUNDS\n")
+				bounds = true
+			}
+			fmt.Fprintf(b, " %s BND %s", bound[0], exportName(v.Name))
+			if bound[1] != "" {
+				fmt.Fprintf(b, " %s", bound[1])
+			}
+			fmt.Fprintf(b, "\n")
+		}
+	}
+
+	if len(f.Q.Values) > 0 {
+		fmt.Fprintf(b, "QUADOBJ\n")
+		for j := 0; j < f.Q.NumCols; j++ {
+			for k := f.Q.Start[j]; k < f.Q.Start[j+1]; k++ {
+				if i := f.Q.Index[k]; i <= j {
+					fmt.Fprintf(b, "    %s %s %s\n", exportName(vars[i].Name), exportName(vars[j].Name), formatNumber(f.Q.Values[k]))
+				}
+			}
+		}
+	}
+	fmt.Fprintf(b, "ENDATA\n")
+	return b.Flush()
+}
+
+func mpsRowType(sense ConstraintSense) string {
+	switch sense {
+	case ConstraintGreaterThan:
+		return "G"
+	case ConstraintLessThan, ConstraintRange:
+		return "L"
+	case ConstraintEqualTo:
+		return "E"
+	}
+	return "N"
+}
+
+/* Get the BOUNDS entries for a variable as pairs of bound type and value, which is empty for types without
+   a value. Continuous variables default to [0, inf) */
+func mpsBounds(v Variable) [][2]string {
+	lowerIsInf := isInfiniteVariableBound(v.LowerBound)
+	upperIsInf := isInfiniteVariableBound(v.UpperBound)
+	switch {
+	case v.Type == VariableBinary:
+		return [][2]string{{"BV", ""}}
+	case v.LowerBound == v.UpperBound:
+		return [][2]string{{"FX", formatNumber(v.LowerBound)}}
+	case lowerIsInf && upperIsInf:
+		return [][2]string{{"FR", ""}}
+	}
+	bounds := make([][2]string, 0, 2)
+	if lowerIsInf {
+		bounds = append(bounds, [2]string{"MI", ""})
+	} else if v.LowerBound != 0 || v.UpperBound < 0 {
+		/* Some readers make the lower bound -inf for a negative upper bound unless it's given */
+		bounds = append(bounds, [2]string{"LO", formatNumber(v.LowerBound)})
+	}
+	if !upperIsInf {
+		bounds = append(bounds, [2]string{"UP", formatNumber(v.UpperBound)})
+	} else if v.Type == VariableInteger {
+		/* Some readers default the upper bound of integer variables to 1 */
+		bounds = append(bounds, [2]string{"PL", ""})
+	}
+	return bounds
+}
+
+/* Get the objective to export with its quadratic form. Problems without objectives get an empty
+   objective to minimize */
+func (p *Problem) exportObjective() (Objective, *QuadraticFunction, error) {
+	objs := p.Objectives()
+	if len(objs) == 0 {
+		empty := &QuadraticFunction{Q: &SparseMatrix{ColumnMajor: true, Start: []int{0}}}
+		return Objective{Name: "obj", Sense: ObjectiveMin, Shape: Constant, Index: -1, p: p}, empty, nil
+	}
+	f, err := objs[0].Quadratic()
+	if err != nil {
+		return Objective{}, nil, err
+	}
+	return objs[0], f, nil
+}
+
+/* Check that the constraints can be written as linear, or quadratic if allowed, constraints */
+func checkExportConstraints(cons []Constraint, quadratic bool) error {
+	for _, c := range cons {
+		if c.Shape == NonLinear || (c.Shape == Quadratic && !quadratic) {
+			return fmt.Errorf("Error: Constraint %s is %s and can't be exported", c.Name, strings.ToLower(c.Shape.String()))
+		}
+		if c.Sense == ConstraintComplementarity {
+			return fmt.Errorf("Error: Complementarity constraint %s can't be exported", c.Name)
+		}
+	}
+	return nil
+}
+
+/* The name of the problem for exported files, the `.nl` file name without its extension */
+func (p *Problem) exportProblemName() string {
+	return strings.TrimSuffix(filepath.Base(p.path), ".nl")
+}
+
+/* Replace whitespace in a name, which separates fields in exported files */
+func exportName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+func formatNumber(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testMIQPModelFile = "miqp.nl"
+
+/* Variables with no linear coefficients, like ones that only appear in the quadratic objective, still get a
+   column */
+func TestWriteMPSEmptyColumn(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile("quadonly.nl")
+	var buf bytes.Buffer
+	assert.Nil(p.WriteMPS(&buf), "Write MPS")
+	assert.Equal(buf.String(), `NAME quadonly
+ROWS
+ N _sobj[1]
+ G _scon[1]
+COLUMNS
+    _svar[1] _sobj[1] 0
+    _svar[2] _sobj[1] 1
+    _svar[2] _scon[1] 1
+RHS
+    RHS _scon[1] 1
+BOUNDS
+ FR BND _svar[1]
+ FR BND _svar[2]
+QUADOBJ
+    _svar[1] _svar[1] 2
+ENDATA
+`, "MPS file")
+}
+
+/* Write a mixed-integer problem with a quadratic objective, using the names from the .col and .row files */
+func TestWriteMPS(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testMIQPModelFile)
+	var buf bytes.Buffer
+	assert.Nil(p.WriteMPS(&buf), "Write MPS")
+	assert.Equal(buf.String(), `NAME miqp
+OBJSENSE
+    MAX
+ROWS
+ N profit
+ L c1
+ L c2
+COLUMNS
+    x profit 2
+    x c1 1
+    x c2 1
+    MARKER 'MARKER' 'INTORG'
+    z profit 1
+    z c1 1
+    y profit 3
+    y c1 1
+    y c2 -1
+    MARKER 'MARKER' 'INTEND'
+RHS
+    RHS profit -4
+    RHS c1 10
+    RHS c2 5
+RANGES
+    RNG c2 4
+BOUNDS
+ BV BND z
+ LO BND y -5
+ UP BND y 20
+QUADOBJ
+    x x -2
+ENDATA
+`, "MPS file")
+}
+
+/* Quadratic constraints can't be written to MPS */
+func TestWriteMPSQuadraticConstraint(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testQuadraticModelFile)
+	var buf bytes.Buffer
+	assert.Error(p.WriteMPS(&buf), "Quadratic constraint")
+}
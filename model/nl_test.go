@@ -14,15 +14,15 @@ func TestDietVariables(t *testing.T) {
 	p := ProblemFromFile(testModelFile)
 	vars := p.Variables()
 	assert.Equal(len(vars), 9, "Number of variables")
-	assert.Equal(vars[0], Variable{"_svar[1]", VariableInteger, 0, 11, 0}, "variable 1")
-	assert.Equal(vars[1], Variable{"_svar[2]", VariableInteger, 0, 10, 1}, "variable 2")
-	assert.Equal(vars[2], Variable{"_svar[3]", VariableInteger, 0, 8, 2}, "variable 3")
-	assert.Equal(vars[3], Variable{"_svar[4]", VariableInteger, 0, 9, 3}, "variable 4")
-	assert.Equal(vars[4], Variable{"_svar[5]", VariableInteger, 0, 8, 4}, "variable 5")
-	assert.Equal(vars[5], Variable{"_svar[6]", VariableInteger, 0, 14, 5}, "variable 6")
-	assert.Equal(vars[6], Variable{"_svar[7]", VariableInteger, 0, 13, 6}, "variable 7")
-	assert.Equal(vars[7], Variable{"_svar[8]", VariableInteger, 0, 31, 7}, "variable 8")
-	assert.Equal(vars[8], Variable{"_svar[9]", VariableInteger, 0, 18, 8}, "variable 9")
+	assert.Equal(vars[0], Variable{Name: "_svar[1]", Type: VariableInteger, LowerBound: 0, UpperBound: 11, Index: 0}, "variable 1")
+	assert.Equal(vars[1], Variable{Name: "_svar[2]", Type: VariableInteger, LowerBound: 0, UpperBound: 10, Index: 1}, "variable 2")
+	assert.Equal(vars[2], Variable{Name: "_svar[3]", Type: VariableInteger, LowerBound: 0, UpperBound: 8, Index: 2}, "variable 3")
+	assert.Equal(vars[3], Variable{Name: "_svar[4]", Type: VariableInteger, LowerBound: 0, UpperBound: 9, Index: 3}, "variable 4")
+	assert.Equal(vars[4], Variable{Name: "_svar[5]", Type: VariableInteger, LowerBound: 0, UpperBound: 8, Index: 4}, "variable 5")
+	assert.Equal(vars[5], Variable{Name: "_svar[6]", Type: VariableInteger, LowerBound: 0, UpperBound: 14, Index: 5}, "variable 6")
+	assert.Equal(vars[6], Variable{Name: "_svar[7]", Type: VariableInteger, LowerBound: 0, UpperBound: 13, Index: 6}, "variable 7")
+	assert.Equal(vars[7], Variable{Name: "_svar[8]", Type: VariableInteger, LowerBound: 0, UpperBound: 31, Index: 7}, "variable 8")
+	assert.Equal(vars[8], Variable{Name: "_svar[9]", Type: VariableInteger, LowerBound: 0, UpperBound: 18, Index: 8}, "variable 9")
 }
 
 /* Get the list of constraints in the diet problem */
@@ -121,3 +121,45 @@ func TestDietConstraintJacobian(t *testing.T) {
 	assert.Nil(err, "No error")
 	assert.Equal(conVals, []float64{510, 34, 28, 15, 6, 30, 20, 370, 35, 24, 15, 10, 20, 20, 500, 42, 25, 6, 2, 25, 20, 370, 38, 14, 2, 15, 10, 400, 42, 31, 8, 15, 15, 8, 220, 26, 3, 15, 2, 345, 27, 15, 4, 20, 15, 110, 12, 9, 10, 4, 30, 80, 20, 1, 2, 120, 2, 2, 0, 0, 0, 0, 0}, "Constraint values")
 }
+
+/* Get the sparsity structure of the constraint Jacobian for the diet problem */
+func TestDietJacobianStructure(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	assert.Equal(p.JacobianNonZeros(), 58, "Number of nonzeros")
+	rows, cols := p.JacobianStructure()
+	assert.Equal(rows, []int{0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 4, 6, 0, 1, 2, 3, 5, 6, 0, 1, 2, 3, 4, 5, 0, 1, 2, 3, 4, 5, 6}, "Jacobian rows")
+	assert.Equal(cols, []int{0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 3, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6, 7, 7, 7, 7, 7, 7, 8, 8, 8, 8, 8, 8, 8}, "Jacobian columns")
+}
+
+/* Get the nonzeros of the constraint Jacobian for the diet problem */
+func TestDietJacobianValues(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	jac := make([]float64, p.JacobianNonZeros())
+	err := p.JacobianValues([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0}, jac)
+	assert.Nil(err, "No error")
+	assert.Equal(jac, []float64{510, 34, 28, 15, 6, 30, 20, 370, 35, 24, 15, 10, 20, 20, 500, 42, 25, 6, 2, 25, 20, 370, 38, 14, 2, 15, 10, 400, 42, 31, 8, 15, 15, 8, 220, 26, 3, 15, 2, 345, 27, 15, 4, 20, 15, 110, 12, 9, 10, 4, 30, 80, 20, 1, 2, 120, 2, 2}, "Jacobian values")
+	err = p.JacobianValues([]float64{0, 1, 0, 0, 0, 0, 0, 0, 0}, make([]float64, 3))
+	assert.NotNil(err, "Wrong buffer size")
+}
+
+/* Get the starting point and initial duals from the .nl file */
+func TestInitialValues(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	x, given := p.InitialPrimal()
+	assert.Equal(x, []float64{1, 2}, "Initial primal")
+	assert.Equal(given, []bool{true, true}, "Primal mask")
+	y, given := p.InitialDual()
+	assert.Equal(y, []float64{0, 0}, "No initial dual")
+	assert.Equal(given, []bool{false, false}, "Dual mask")
+
+	p = ProblemFromFile(testQuadraticModelFile)
+	x, given = p.InitialPrimal()
+	assert.Equal(x, []float64{0, 0, 1.5}, "Initial primal")
+	assert.Equal(given, []bool{false, false, true}, "Primal mask")
+	y, given = p.InitialDual()
+	assert.Equal(y, []float64{0, 0.5}, "Initial dual")
+	assert.Equal(given, []bool{false, true}, "Dual mask")
+}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"github.com/alanctgardner/ampl-go/nl"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+/* The problems to read with both AMPL's reader and the pure Go reader */
+var testReaderFiles = []string{
+	testModelFile,
+	testNonLinearModelFile,
+	testQuadraticModelFile,
+	testMIQPModelFile,
+	testSuffixModelFile,
+	"defvar.nl",
+	"domain.nl",
+	"indicator.nl",
+	"mpec.nl",
+	"defvar_binary.nl",
+	"suffix_binary.nl",
+	"operators.nl",
+}
+
+/* The pure Go reader finds the same variables, constraints and objectives as AMPL's reader */
+func TestPureGoReader(t *testing.T) {
+	assert := assert.New(t)
+	for _, file := range testReaderFiles {
+		p, err := LoadProblemWithOptions(file, LoadOptions{LogicalConstraints: true})
+		assert.Nil(err, "No error")
+		q, err := nl.LoadProblem(file)
+		assert.Nil(err, "No error")
+
+		assert.Equal(q.Description(), p.Description(), file)
+		vars, goVars := p.Variables(), q.Variables()
+		for i, v := range goVars {
+			assert.Equal(v.Name, vars[i].Name, file)
+			assert.Equal(v.Type, vars[i].Type, file)
+		}
+		cons, goCons := p.Constraints(), q.Constraints()
+		for i, c := range goCons {
+			assert.Equal(c.Shape, cons[i].Shape, file)
+			assert.Equal(len(c.Variables), len(cons[i].Variables), file)
+		}
+		objs, goObjs := p.Objectives(), q.Objectives()
+		for i, o := range goObjs {
+			assert.Equal(o.Shape, objs[i].Shape, file)
+			assert.Equal(o.Constant(), objs[i].Constant(), file)
+		}
+		assert.Equal(q.ComplementedVariables(), p.ComplementedVariables(), file)
+		assert.Equal(q.NumLogicalConstraints(), p.NumLogicalConstraints(), file)
+
+		x0, haveX0 := p.InitialPrimal()
+		goX0, goHaveX0 := q.InitialPrimal()
+		assert.Equal(goX0, x0, file)
+		assert.Equal(goHaveX0, haveX0, file)
+		pi0, havePi0 := p.InitialDual()
+		goPi0, goHavePi0 := q.InitialDual()
+		assert.Equal(goPi0, pi0, file)
+		assert.Equal(goHavePi0, havePi0, file)
+		p.Close()
+	}
+}
+
+/* The pure Go reader reads the suffixes AMPL's reader reads when they're declared */
+func TestPureGoReaderSuffixes(t *testing.T) {
+	assert := assert.New(t)
+	for _, file := range []string{testSuffixModelFile, "suffix_binary.nl"} {
+		p, err := LoadProblemWithSuffixes(file, testSuffixDecls)
+		assert.Nil(err, "No error")
+		q, err := nl.LoadProblem(file)
+		assert.Nil(err, "No error")
+		for _, decl := range testSuffixDecls[:3] {
+			suf, err := p.Suffix(decl.Name, decl.Kind)
+			assert.Nil(err, "No error")
+			goSuf, err := q.Suffix(decl.Name, nl.SuffixKind(decl.Kind))
+			assert.Nil(err, "No error")
+			assert.Equal(goSuf.Values, suf.Values, decl.Name)
+			assert.Equal(goSuf.Real, suf.Real, decl.Name)
+		}
+		p.Close()
+	}
+}
+
+/* A point inside the bounds of every variable, away from 0 so the test problems can be evaluated */
+func testEvalPoint(vars []Variable) []float64 {
+	x := make([]float64, len(vars))
+	for i, v := range vars {
+		x[i] = 1.5 + 0.25*float64(i%4)
+		if x[i] > v.UpperBound {
+			x[i] = v.UpperBound
+		}
+		if x[i] < v.LowerBound {
+			x[i] = v.LowerBound
+		}
+	}
+	return x
+}
+
+/* The pure Go evaluator gives the same values, gradients and Hessians as AMPL's solver library */
+func TestPureGoEvaluator(t *testing.T) {
+	assert := assert.New(t)
+	for _, file := range testReaderFiles {
+		p, err := LoadProblemWithOptions(file, LoadOptions{LogicalConstraints: true})
+		assert.Nil(err, "No error")
+		q, err := nl.LoadProblem(file)
+		assert.Nil(err, "No error")
+		x := testEvalPoint(p.Variables())
+
+		cons, goCons := p.Constraints(), q.Constraints()
+		for i, c := range goCons {
+			val, err := cons[i].Value(x)
+			assert.Nil(err, "No error")
+			goVal, err := c.Value(x)
+			assert.Nil(err, "No error")
+			assert.InDelta(goVal, val, 1e-9, file)
+			grad, err := cons[i].Gradient(x)
+			assert.Nil(err, "No error")
+			goGrad, err := c.Gradient(x)
+			assert.Nil(err, "No error")
+			assert.InDeltaSlice(goGrad, grad, 1e-9, file)
+		}
+		objs, goObjs := p.Objectives(), q.Objectives()
+		for i, o := range goObjs {
+			val, err := objs[i].Value(x)
+			assert.Nil(err, "No error")
+			goVal, err := o.Value(x)
+			assert.Nil(err, "No error")
+			assert.InDelta(goVal, val, 1e-9, file)
+			grad, err := objs[i].Gradient(x)
+			assert.Nil(err, "No error")
+			goGrad, err := o.Gradient(x)
+			assert.Nil(err, "No error")
+			assert.InDeltaSlice(goGrad, grad, 1e-9, file)
+		}
+		vals, err := p.ConstraintValues(x)
+		assert.Nil(err, "No error")
+		goVals, err := q.ConstraintValues(x)
+		assert.Nil(err, "No error")
+		assert.InDeltaSlice(goVals, vals, 1e-9, file)
+
+		objWeights := make([]float64, len(objs))
+		for i := range objWeights {
+			objWeights[i] = 1 + float64(i)
+		}
+		multipliers := make([]float64, len(cons))
+		for i := range multipliers {
+			multipliers[i] = 0.5 - 0.25*float64(i)
+		}
+		hes, err := p.FullHessian(x, -1, objWeights, multipliers)
+		assert.Nil(err, "No error")
+		goHes, err := q.FullHessian(x, -1, objWeights, multipliers)
+		assert.Nil(err, "No error")
+		assert.InDeltaSlice(goHes, hes, 1e-9, file)
+		if len(objs) > 0 {
+			hes, err = p.FullHessian(x, 0, nil, nil)
+			assert.Nil(err, "No error")
+			goHes, err = q.FullHessian(x, 0, nil, nil)
+			assert.Nil(err, "No error")
+			assert.InDeltaSlice(goHes, hes, 1e-9, file)
+		}
+		direction := testEvalPoint(p.Variables())
+		hv, err := p.HessianVectorProduct(x, direction, -1, objWeights, multipliers)
+		assert.Nil(err, "No error")
+		goHv, err := q.HessianVectorProduct(x, direction, -1, objWeights, multipliers)
+		assert.Nil(err, "No error")
+		assert.InDeltaSlice(goHv, hv, 1e-9, file)
+		p.Close()
+	}
+}
+
+/* The pure Go evaluator returns the same errors as AMPL's solver library */
+func TestPureGoEvaluatorErrors(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testDomainModelFile)
+	q, err := nl.LoadProblem(testDomainModelFile)
+	assert.Nil(err, "No error")
+	_, err = p.Constraints()[0].Value([]float64{-1})
+	_, goErr := q.Constraints()[0].Value([]float64{-1})
+	assert.Equal(goErr.Error(), err.Error(), "Value error")
+	_, err = p.Objectives()[0].Value([]float64{-4})
+	_, goErr = q.Objectives()[0].Value([]float64{-4})
+	assert.Equal(goErr.Error(), err.Error(), "Value error")
+	_, err = p.Objectives()[0].Gradient([]float64{0})
+	_, goErr = q.Objectives()[0].Gradient([]float64{0})
+	assert.Equal(goErr.Error(), err.Error(), "Gradient error")
+	_, err = p.FullHessian([]float64{-1}, 0, nil, nil)
+	_, goErr = q.FullHessian([]float64{-1}, 0, nil, nil)
+	assert.Equal(goErr.Error(), err.Error(), "Hessian error")
+	p.Close()
+}
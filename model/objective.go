@@ -1,22 +1,16 @@
 package model
 
-type ObjectiveSense int
+import (
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+type ObjectiveSense = types.ObjectiveSense
 
 const (
-	ObjectiveMin ObjectiveSense = 0
-	ObjectiveMax ObjectiveSense = 1
+	ObjectiveMin = types.ObjectiveMin
+	ObjectiveMax = types.ObjectiveMax
 )
 
-func (s ObjectiveSense) String() string {
-	if s == ObjectiveMin {
-		return "min"
-	} else if s == ObjectiveMax{
-		return "max"
-	} else {
-		return "unknown"
-	}
-}
-
 /* Represents a single objective in the problem */
 type Objective struct {
 	Name string
@@ -192,6 +192,8 @@ obj1grd_ASL(ASL *a, int i, real *X, real *G, fint *nerror)
 		}
 	if (asl->i.Derrs)
 		deriv_errchk_ASL(a, nerror, -(i+1), 1);
+	if (ne0 >= 0 && *nerror)
+		goto done;
 	if (f_b)
 		funnelset_ASL(asl, f_b);
 	if (f_o)
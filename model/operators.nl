@@ -0,0 +1,172 @@
+g3 1 1 0	# problem operators
+ 3 5 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 5 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 3 3 3	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 15 3	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 1 0 0 0 0	# common exprs: b,c,o,c1,o1
+V3 1 0	#d
+0 2
+o43	#log
+v1	#y
+C0	#trig
+o54	#sumlist
+6
+o41	#sin
+v0	#x
+o46	#cos
+v1	#y
+o38	#tan
+o2	#*
+n0.3
+v2	#z
+o37	#tanh
+v0	#x
+o40	#sinh
+v1	#y
+o45	#cosh
+v2	#z
+C1	#inverse
+o54	#sumlist
+7
+o47	#atanh
+o1	#-
+v0	#x
+v1	#y
+o51	#asin
+o1	#-
+v1	#y
+v2	#z
+o53	#acos
+o3	#/
+v0	#x
+v2	#z
+o52	#acosh
+o2	#*
+v0	#x
+v1	#y
+o50	#asinh
+v2	#z
+o49	#atan
+v0	#x
+o48	#atan2
+v1	#y
+v2	#z
+C2	#powers
+o54	#sumlist
+6
+o5	#^
+v0	#x
+n3
+o5	#^
+n2
+v1	#y
+o5	#^
+v0	#x
+v2	#z
+o42	#log10
+v1	#y
+o44	#exp
+o2	#*
+n0.5
+v2	#z
+o39	#sqrt
+o0	#+
+v0	#x
+v1	#y
+C3	#nonsmooth
+o54	#sumlist
+8
+o11	#min
+3
+v0	#x
+v1	#y
+v2	#z
+o12	#max
+3
+v0	#x
+v1	#y
+v2	#z
+o15	#abs
+o1	#-
+v0	#x
+v2	#z
+o16	#-
+o2	#*
+v0	#x
+v1	#y
+o6	#less
+v2	#z
+v0	#x
+o64	#pl
+3
+n-1
+n0
+n1
+n2
+n3
+v1	#y
+o4	#mod
+v1	#y
+v0	#x
+o13	#floor
+v2	#z
+C4	#defined
+o0	#+
+o3	#/
+v3	#d
+o0	#+
+v0	#x
+n1
+o35	#if
+o29	#>
+v0	#x
+n1
+o2	#*
+v1	#y
+v2	#z
+v2	#z
+O0 0	#f
+o2	#*
+v3	#d
+v3	#d
+r	#5 ranges (rhs's)
+1 100
+1 100
+1 100
+1 100
+1 100
+b	#3 bounds (on variables)
+0 1 3
+0 1 3
+0 1 3
+k2	#intermediate Jacobian column lengths
+5
+10
+J0 3
+0 1
+1 0
+2 0
+J1 3
+0 0
+1 0
+2 0
+J2 3
+0 0
+1 0
+2 0
+J3 3
+0 0
+1 0
+2 -1
+J4 3
+0 0
+1 0
+2 1
+G0 3
+0 0
+1 0
+2 0
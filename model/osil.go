@@ -0,0 +1,458 @@
+package model
+
+/*
+#include "asl.h"
+#include "nlp.h"
+#include <stddef.h>
+#include <stdlib.h>
+
+// pfgh_read splits the expressions into partially separable parts, so OSiL is written from a copy of the
+// problem read with fg_read, which keeps the expression graph from the .nl file. The op codes are the
+// indices into r_op.hd
+
+int readProblem(ASL *asl, char *stub, int flags, long *line, char **msg);
+
+int fgOpcode(expr *e) {
+	int k;
+
+	for(k = 0; k < 82; k++)
+		if (r_ops_ASL[k] && e->op == r_ops_ASL[k])
+			return k;
+	return -1;
+}
+
+// The index into var_e of a variable node. Indices from n_var are defined variables
+int fgVariable(ASL *asl, expr *e) {
+	return (int)((expr_v*)e - ((ASL_fg*)asl)->I.var_e_);
+}
+
+// The expression and linear part of a defined variable, from cexps or cexps1
+expr *fgDefinedVariable(ASL *asl, int k, int *nlin, linpart **L) {
+	ASL_fg *a = (ASL_fg*)asl;
+	int n = k - asl->i.n_var_;
+
+	if (n < asl->i.ncom0_) {
+		*nlin = a->I.cexps_[n].nlin;
+		*L = a->I.cexps_[n].L;
+		return a->I.cexps_[n].e;
+	}
+	n -= asl->i.ncom0_;
+	*nlin = a->I.cexps1_[n].nlin;
+	*L = a->I.cexps1_[n].L;
+	return a->I.cexps1_[n].e;
+}
+
+// The variable index of a linear term, which points at the value of the variable
+int fgLinpartVariable(ASL *asl, linpart *L, int i) {
+	expr_v *v = (expr_v*)((char*)L[i].v.rp - offsetof(expr_v, v));
+	return (int)(v - ((ASL_fg*)asl)->I.var_e_);
+}
+
+real fgLinpartCoefficient(linpart *L, int i) {
+	return L[i].fac;
+}
+
+real fgNumber(expr *e) {
+	return ((expr_n*)e)->v;
+}
+
+expr *fgLeft(expr *e) {
+	return e->L.e;
+}
+
+expr *fgRight(expr *e) {
+	return e->R.e;
+}
+
+int fgListLen(expr *e) {
+	return (int)(e->R.ep - e->L.ep);
+}
+
+expr *fgListItem(expr *e, int i) {
+	return e->L.ep[i];
+}
+
+// The arguments of min and max end with a null expression
+expr *fgVarargItem(expr *e, int i) {
+	return ((expr_va*)e)->L.d[i].e;
+}
+
+expr *fgIfCond(expr *e) {
+	return ((expr_if*)e)->e;
+}
+
+expr *fgIfThen(expr *e) {
+	return ((expr_if*)e)->T;
+}
+
+expr *fgIfElse(expr *e) {
+	return ((expr_if*)e)->F;
+}
+
+expr *fgConstraint(ASL *asl, int i) {
+	return ((ASL_fg*)asl)->I.con_de_[i].e;
+}
+
+expr *fgObjective(ASL *asl, int i) {
+	return ((ASL_fg*)asl)->I.obj_de_[i].e;
+}
+*/
+import "C"
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unsafe"
+)
+
+/* Op codes used in expressions, besides those in logical.go */
+const (
+	opPow      = 5
+	opLess     = 6
+	opMinList  = 11
+	opMaxList  = 12
+	opOr       = 20
+	opIf       = 35
+	opOrList   = 71
+	opAllDiff  = 74
+	opPowConst = 75
+	opSquare   = 76
+	opConstPow = 77
+)
+
+/* The OSnL elements for AMPL's unary, binary and logical operators */
+var osnlOperators = map[int]string{
+	opPlus: "plus", opMinus: "minus", opMult: "times", opDiv: "divide", opPow: "power", opPowConst: "power",
+	opConstPow: "power", 13: "floor", 14: "ceiling", 15: "abs", opUMinus: "negate", 34: "not", 37: "tanh",
+	38: "tan", 39: "squareRoot", 40: "sinh", 41: "sin", 42: "log10", 43: "ln", 44: "exp", 45: "cosh",
+	46: "cos", 47: "arctanh", 49: "arctan", 50: "arcsinh", 51: "arcsin", 52: "arccosh", 53: "arccos",
+	opOr: "or", opAnd: "and", opLT: "lt", opLE: "leq", opEQ: "eq", opGE: "geq", opGT: "gt", opNE: "ne",
+}
+
+/* Write the problem as an OSiL (Optimization Services instance language) XML document, including the
+   expressions of nonlinear constraints and objectives. Defined variables are expanded where they're used.
+   Returns an error if the problem has complementarity or logical constraints, uses operators OSnL
+   doesn't have, such as piecewise-linear terms and imported functions, or was changed after it was read by
+   `MPECAdjust` or scaling, since the expressions are read again from the `.nl` file */
+func (p *Problem) WriteOSiL(w io.Writer) error {
+	vars := p.Variables()
+	cons := p.Constraints()
+	objs := p.Objectives()
+	for _, c := range cons {
+		if c.Sense == ConstraintComplementarity {
+			return fmt.Errorf("Error: Complementarity constraint %s can't be exported", c.Name)
+		}
+	}
+	if p.NumLogicalConstraints() > 0 {
+		return fmt.Errorf("Error: Logical constraints can't be exported")
+	}
+	if p.options.MPECAdjust {
+		return fmt.Errorf("Error: Problems loaded with MPECAdjust can't be exported")
+	}
+	if p.isScaled() {
+		return fmt.Errorf("Error: Scaled problems can't be exported")
+	}
+	asl, err := p.readExpressionGraph()
+	if err != nil {
+		return err
+	}
+	defer C.ASL_free(&asl)
+
+	/* Write the expressions first, so nothing is written if one can't be converted */
+	nl := &osilWriter{asl: asl, numVars: len(vars)}
+	numExpressions := 0
+	for _, o := range objs {
+		if o.Shape == NonLinear || o.Shape == Quadratic {
+			if err := nl.expression(-1-o.Index, C.fgObjective(asl, C.int(o.Index))); err != nil {
+				return fmt.Errorf("Error: Can't export objective %s: %v", o.Name, err)
+			}
+			numExpressions++
+		}
+	}
+	for _, c := range cons {
+		if c.Shape == NonLinear || c.Shape == Quadratic {
+			if err := nl.expression(c.Index, C.fgConstraint(asl, C.int(c.Index))); err != nil {
+				return fmt.Errorf("Error: Can't export constraint %s: %v", c.Name, err)
+			}
+			numExpressions++
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(b, "<osil xmlns=\"os.optimizationservices.org\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xsi:schemaLocation=\"os.optimizationservices.org http://www.optimizationservices.org/schemas/2.0/OSiL.xsd\">\n")
+	fmt.Fprintf(b, "  <instanceHeader>\n    <name>%s</name>\n  </instanceHeader>\n", xmlEscape(p.exportProblemName()))
+	fmt.Fprintf(b, "  <instanceData>\n")
+
+	fmt.Fprintf(b, "    <variables numberOfVariables=\"%d\">\n", len(vars))
+	for _, v := range vars {
+		fmt.Fprintf(b, "      <var name=\"%s\" type=\"%s\" lb=\"%s\" ub=\"%s\"/>\n", xmlEscape(v.Name), osilType(v.Type), osilBound(v.LowerBound), osilBound(v.UpperBound))
+	}
+	fmt.Fprintf(b, "    </variables>\n")
+
+	if len(objs) > 0 {
+		fmt.Fprintf(b, "    <objectives numberOfObjectives=\"%d\">\n", len(objs))
+		for _, o := range objs {
+			terms := make([]LinearTerm, 0)
+			for _, term := range o.LinearTerms() {
+				if term.Coefficient != 0 {
+					terms = append(terms, term)
+				}
+			}
+			fmt.Fprintf(b, "      <obj name=\"%s\" maxOrMin=\"%s\" numberOfObjCoef=\"%d\"", xmlEscape(o.Name), o.Sense, len(terms))
+			if e := C.fgObjective(asl, C.int(o.Index)); C.fgOpcode(e) == opNumber && C.fgNumber(e) != 0 {
+				fmt.Fprintf(b, " constant=\"%s\"", formatNumber(float64(C.fgNumber(e))))
+			}
+			fmt.Fprintf(b, ">\n")
+			for _, term := range terms {
+				fmt.Fprintf(b, "        <coef idx=\"%d\">%s</coef>\n", term.Variable.Index, formatNumber(term.Coefficient))
+			}
+			fmt.Fprintf(b, "      </obj>\n")
+		}
+		fmt.Fprintf(b, "    </objectives>\n")
+	}
+
+	if len(cons) > 0 {
+		fmt.Fprintf(b, "    <constraints numberOfConstraints=\"%d\">\n", len(cons))
+		for _, c := range cons {
+			fmt.Fprintf(b, "      <con name=\"%s\"", xmlEscape(c.Name))
+			if !math.IsInf(c.Min, 0) {
+				fmt.Fprintf(b, " lb=\"%s\"", formatNumber(c.Min))
+			}
+			if !math.IsInf(c.Max, 0) {
+				fmt.Fprintf(b, " ub=\"%s\"", formatNumber(c.Max))
+			}
+			fmt.Fprintf(b, "/>\n")
+		}
+		fmt.Fprintf(b, "    </constraints>\n")
+
+		/* The Jacobian has entries for variables that only appear nonlinearly, which are left out */
+		m := p.LinearMatrixCSC()
+		start := make([]int, 0, len(m.Start))
+		rows := make([]int, 0, len(m.Index))
+		values := make([]float64, 0, len(m.Values))
+		for j := 0; j < m.NumCols; j++ {
+			start = append(start, len(rows))
+			for k := m.Start[j]; k < m.Start[j+1]; k++ {
+				if m.Values[k] != 0 {
+					rows = append(rows, m.Index[k])
+					values = append(values, m.Values[k])
+				}
+			}
+		}
+		start = append(start, len(rows))
+		if len(rows) > 0 {
+			fmt.Fprintf(b, "    <linearConstraintCoefficients numberOfValues=\"%d\">\n", len(rows))
+			writeOSiLArray(b, "start", start, nil)
+			writeOSiLArray(b, "rowIdx", rows, nil)
+			writeOSiLArray(b, "value", nil, values)
+			fmt.Fprintf(b, "    </linearConstraintCoefficients>\n")
+		}
+	}
+
+	if numExpressions > 0 {
+		fmt.Fprintf(b, "    <nonlinearExpressions numberOfNonlinearExpressions=\"%d\">\n", numExpressions)
+		b.WriteString(nl.buf.String())
+		fmt.Fprintf(b, "    </nonlinearExpressions>\n")
+	}
+	fmt.Fprintf(b, "  </instanceData>\n</osil>\n")
+	return b.Flush()
+}
+
+/* Read the problem again with fg_read, which keeps the expression graph. The caller must free the result */
+func (p *Problem) readExpressionGraph() (*C.ASL, error) {
+	pathC := C.CString(p.path)
+	defer C.free(unsafe.Pointer(pathC))
+	aslLock.Lock()
+	defer aslLock.Unlock()
+	asl := C.ASL_alloc(C.ASL_read_fg)
+	var line C.long
+	var msg *C.char
+	code := C.readProblem(asl, pathC, C.ASL_allow_missing_funcs, &line, &msg)
+	message := strings.TrimSpace(C.GoString(msg))
+	C.free(unsafe.Pointer(msg))
+	if code != C.ASL_readerr_none {
+		C.ASL_free(&asl)
+		return nil, &ReadError{Path: p.path, Kind: ReadErrorKind(code), Line: int(line), Message: message}
+	}
+	return asl, nil
+}
+
+func osilType(t VariableType) string {
+	switch t {
+	case VariableBinary:
+		return "B"
+	case VariableInteger:
+		return "I"
+	}
+	return "C"
+}
+
+func osilBound(x float64) string {
+	if isInfiniteVariableBound(x) {
+		if x < 0 {
+			return "-INF"
+		}
+		return "INF"
+	}
+	return formatNumber(x)
+}
+
+/* Write an array as <el> elements, from ints or floats */
+func writeOSiLArray(b *bufio.Writer, name string, ints []int, floats []float64) {
+	fmt.Fprintf(b, "      <%s>\n", name)
+	for _, x := range ints {
+		fmt.Fprintf(b, "        <el>%d</el>\n", x)
+	}
+	for _, x := range floats {
+		fmt.Fprintf(b, "        <el>%s</el>\n", formatNumber(x))
+	}
+	fmt.Fprintf(b, "      </%s>\n", name)
+}
+
+func xmlEscape(s string) string {
+	var buf strings.Builder
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+/* Converts ASL expressions to OSnL */
+type osilWriter struct {
+	asl     *C.ASL
+	numVars int
+	buf     strings.Builder
+}
+
+/* Write the <nl> element for the expression of objective or constraint `index`, where objectives are
+   numbered -1, -2, ... */
+func (w *osilWriter) expression(index int, e *C.expr) error {
+	fmt.Fprintf(&w.buf, "      <nl idx=\"%d\">\n", index)
+	if err := w.node(e, 8); err != nil {
+		return err
+	}
+	fmt.Fprintf(&w.buf, "      </nl>\n")
+	return nil
+}
+
+func (w *osilWriter) open(name string, depth int) {
+	fmt.Fprintf(&w.buf, "%s<%s>\n", strings.Repeat(" ", depth), name)
+}
+
+func (w *osilWriter) close(name string, depth int) {
+	fmt.Fprintf(&w.buf, "%s</%s>\n", strings.Repeat(" ", depth), name)
+}
+
+func (w *osilWriter) variable(index int, coef float64, depth int) {
+	fmt.Fprintf(&w.buf, "%s<variable idx=\"%d\" coef=\"%s\"/>\n", strings.Repeat(" ", depth), index, formatNumber(coef))
+}
+
+func (w *osilWriter) number(x float64, depth int) {
+	fmt.Fprintf(&w.buf, "%s<number value=\"%s\"/>\n", strings.Repeat(" ", depth), formatNumber(x))
+}
+
+/* Write the elements in `name` around each of the arguments */
+func (w *osilWriter) apply(name string, depth int, args ...*C.expr) error {
+	w.open(name, depth)
+	for _, arg := range args {
+		if err := w.node(arg, depth+2); err != nil {
+			return err
+		}
+	}
+	w.close(name, depth)
+	return nil
+}
+
+func (w *osilWriter) node(e *C.expr, depth int) error {
+	op := int(C.fgOpcode(e))
+	switch op {
+	case opNumber:
+		w.number(float64(C.fgNumber(e)), depth)
+		return nil
+	case opVariable:
+		return w.variableNode(int(C.fgVariable(w.asl, e)), depth)
+	case opSquare:
+		return w.apply("square", depth, C.fgLeft(e))
+	case opLess:
+		/* x less y is max(x - y, 0) */
+		w.open("max", depth)
+		if err := w.apply("minus", depth+2, C.fgLeft(e), C.fgRight(e)); err != nil {
+			return err
+		}
+		w.number(0, depth+2)
+		w.close("max", depth)
+		return nil
+	case opMinList, opMaxList:
+		args := make([]*C.expr, 0)
+		for i := 0; C.fgVarargItem(e, C.int(i)) != nil; i++ {
+			args = append(args, C.fgVarargItem(e, C.int(i)))
+		}
+		if op == opMinList {
+			return w.apply("min", depth, args...)
+		}
+		return w.apply("max", depth, args...)
+	case opSumList, opAndList, opOrList, opAllDiff:
+		args := make([]*C.expr, C.fgListLen(e))
+		for i := range args {
+			args[i] = C.fgListItem(e, C.int(i))
+		}
+		return w.apply(map[int]string{opSumList: "sum", opAndList: "and", opOrList: "or", opAllDiff: "allDiff"}[op], depth, args...)
+	case opIf:
+		return w.apply("if", depth, C.fgIfCond(e), C.fgIfThen(e), C.fgIfElse(e))
+	}
+	name, ok := osnlOperators[op]
+	if !ok {
+		return fmt.Errorf("operator %d isn't supported by OSnL", op)
+	}
+	if isBinaryOperator(op) {
+		return w.apply(name, depth, C.fgLeft(e), C.fgRight(e))
+	}
+	return w.apply(name, depth, C.fgLeft(e))
+}
+
+func isBinaryOperator(op int) bool {
+	switch op {
+	case opPlus, opMinus, opMult, opDiv, opPow, opPowConst, opConstPow, opOr, opAnd, opLT, opLE, opEQ, opGE, opGT, opNE:
+		return true
+	}
+	return false
+}
+
+/* Write a variable, or expand a defined variable into its expression plus its linear terms */
+func (w *osilWriter) variableNode(index int, depth int) error {
+	if index < w.numVars {
+		w.variable(index, 1, depth)
+		return nil
+	}
+	var nlin C.int
+	var L *C.linpart
+	e := C.fgDefinedVariable(w.asl, C.int(index), &nlin, &L)
+	if nlin == 0 {
+		return w.node(e, depth)
+	}
+	w.open("sum", depth)
+	if C.fgOpcode(e) != opNumber || C.fgNumber(e) != 0 {
+		if err := w.node(e, depth+2); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < int(nlin); i++ {
+		v := int(C.fgLinpartVariable(w.asl, L, C.int(i)))
+		coef := float64(C.fgLinpartCoefficient(L, C.int(i)))
+		if v < w.numVars {
+			w.variable(v, coef, depth+2)
+			continue
+		}
+		w.open("times", depth+2)
+		w.number(coef, depth+4)
+		if err := w.variableNode(v, depth+4); err != nil {
+			return err
+		}
+		w.close("times", depth+2)
+	}
+	w.close("sum", depth)
+	return nil
+}
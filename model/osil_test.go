@@ -0,0 +1,154 @@
+package model
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testDefinedVariableModelFile = "defvar.nl"
+
+/* Problems which don't match the expressions in their .nl file can't be exported */
+func TestWriteOSiLChangedProblem(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Nil(p.ScaleConstraint(0, 2), "No error")
+	var buf bytes.Buffer
+	assert.EqualError(p.WriteOSiL(&buf), "Error: Scaled problems can't be exported", "Scaled")
+	assert.Equal(buf.Len(), 0, "Nothing written")
+
+	p, err := LoadProblemWithOptions(testMPECModelFile, LoadOptions{MPECAdjust: true})
+	assert.Nil(err, "No error")
+	defer p.Close()
+	assert.EqualError(p.WriteOSiL(&buf), "Error: Problems loaded with MPECAdjust can't be exported", "MPECAdjust")
+}
+
+/* Write a nonlinear problem with its expressions */
+func TestWriteOSiL(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	var buf bytes.Buffer
+	assert.Nil(p.WriteOSiL(&buf), "Write OSiL")
+	assert.Equal(buf.String(), `<?xml version="1.0" encoding="UTF-8"?>
+<osil xmlns="os.optimizationservices.org" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="os.optimizationservices.org http://www.optimizationservices.org/schemas/2.0/OSiL.xsd">
+  <instanceHeader>
+    <name>hs1</name>
+  </instanceHeader>
+  <instanceData>
+    <variables numberOfVariables="2">
+      <var name="_svar[1]" type="C" lb="-INF" ub="INF"/>
+      <var name="_svar[2]" type="C" lb="-10" ub="10"/>
+    </variables>
+    <objectives numberOfObjectives="1">
+      <obj name="_sobj[1]" maxOrMin="min" numberOfObjCoef="0">
+      </obj>
+    </objectives>
+    <constraints numberOfConstraints="2">
+      <con name="_scon[1]" ub="4"/>
+      <con name="_scon[2]" lb="1"/>
+    </constraints>
+    <linearConstraintCoefficients numberOfValues="2">
+      <start>
+        <el>0</el>
+        <el>1</el>
+        <el>2</el>
+      </start>
+      <rowIdx>
+        <el>1</el>
+        <el>1</el>
+      </rowIdx>
+      <value>
+        <el>1</el>
+        <el>2</el>
+      </value>
+    </linearConstraintCoefficients>
+    <nonlinearExpressions numberOfNonlinearExpressions="2">
+      <nl idx="-1">
+        <sum>
+          <square>
+            <variable idx="0" coef="1"/>
+          </square>
+          <times>
+            <variable idx="0" coef="1"/>
+            <variable idx="1" coef="1"/>
+          </times>
+          <exp>
+            <variable idx="1" coef="1"/>
+          </exp>
+        </sum>
+      </nl>
+      <nl idx="0">
+        <plus>
+          <square>
+            <variable idx="0" coef="1"/>
+          </square>
+          <square>
+            <variable idx="1" coef="1"/>
+          </square>
+        </plus>
+      </nl>
+    </nonlinearExpressions>
+  </instanceData>
+</osil>
+`, "OSiL file")
+}
+
+/* Defined variables are expanded where they're used */
+func TestWriteOSiLDefinedVariables(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testDefinedVariableModelFile)
+	var buf bytes.Buffer
+	assert.Nil(p.WriteOSiL(&buf), "Write OSiL")
+	assert.Equal(buf.String(), `<?xml version="1.0" encoding="UTF-8"?>
+<osil xmlns="os.optimizationservices.org" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="os.optimizationservices.org http://www.optimizationservices.org/schemas/2.0/OSiL.xsd">
+  <instanceHeader>
+    <name>defvar</name>
+  </instanceHeader>
+  <instanceData>
+    <variables numberOfVariables="1">
+      <var name="_svar[1]" type="C" lb="-2" ub="2"/>
+    </variables>
+    <objectives numberOfObjectives="1">
+      <obj name="_sobj[1]" maxOrMin="min" numberOfObjCoef="0">
+      </obj>
+    </objectives>
+    <constraints numberOfConstraints="1">
+      <con name="_scon[1]" ub="5"/>
+    </constraints>
+    <nonlinearExpressions numberOfNonlinearExpressions="2">
+      <nl idx="-1">
+        <times>
+          <sum>
+            <sin>
+              <variable idx="0" coef="1"/>
+            </sin>
+            <variable idx="0" coef="2"/>
+          </sum>
+          <sum>
+            <sin>
+              <variable idx="0" coef="1"/>
+            </sin>
+            <variable idx="0" coef="2"/>
+          </sum>
+        </times>
+      </nl>
+      <nl idx="0">
+        <if>
+          <lt>
+            <variable idx="0" coef="1"/>
+            <number value="1"/>
+          </lt>
+          <sum>
+            <sin>
+              <variable idx="0" coef="1"/>
+            </sin>
+            <variable idx="0" coef="2"/>
+          </sum>
+          <number value="3"/>
+        </if>
+      </nl>
+    </nonlinearExpressions>
+  </instanceData>
+</osil>
+`, "OSiL file")
+}
@@ -3936,7 +3936,7 @@ aholread(EdRead *R)
 			fprintf(Stderr,
 				 "Premature end of file in aholread, line %ld of %s\n",
 					R->Line, R->asl->i.filename_);
-				exit_ASL(R,1);
+				exit_ASL(R,ASL_readerr_corrupt);
 			}
 		if (k == '\n') {
 			R->Line++;
@@ -4237,7 +4237,7 @@ pfg_read_ASL(ASL *a, FILE *nl, int flags)
 		i = __builtin_setjmp(JB.jb);
 		if (i) {
 			a->i.err_jmp_ = 0;
-			return i;
+			return JB.err;
 			}
 		}
 	if ((nlogc = a->i.n_lcon_) && !(flags & ASL_allow_CLP)) {
@@ -0,0 +1,61 @@
+g3 1 1 0	# problem qp
+ 3 2 2 0 0	# vars, constraints, objectives, ranges, eqns
+ 1 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 2 2 2	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 4 5	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+o0	#+
+o5	#^
+o0	#+
+v1	#x[2]
+n-1
+n2
+o2	#*
+v0	#x[1]
+v1	#x[2]
+C1	#c2
+n0
+O0 0	#f
+o54	#sumlist
+3
+o5	#^
+v0	#x[1]
+n2
+o2	#*
+v0	#x[1]
+v1	#x[2]
+n1
+O1 1	#g
+n5
+d1	# initial dual guess
+1 0.5
+x1	# initial guess
+2 1.5
+r	#2 ranges (rhs's)
+1 4
+2 1
+b	#3 bounds (on variables)
+3
+3
+3
+k2	#intermediate Jacobian column lengths
+2
+3
+J0 2
+0 1
+1 0
+J1 2
+0 1
+2 2
+G0 3
+0 0
+1 0
+2 3
+G1 2
+0 1
+1 -1
@@ -0,0 +1,28 @@
+g3 1 1 0	# problem quadonly
+ 2 1 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 0 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 0 1 0	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 1 2	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c
+n0
+O0 0	#f
+o5	#^
+v0	#x0
+n2
+r	#1 ranges (rhs's)
+2 1
+b	#2 bounds (on variables)
+3
+3
+k1	#intermediate Jacobian column lengths
+0
+J0 1
+1 1
+G0 2
+0 0
+1 1
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+)
+
+/* A quadratic function 0.5 * x'Qx + sum(Linear[i].Coefficient * x[Linear[i].Variable.Index]) + Constant */
+type QuadraticFunction struct {
+	/* The symmetric Q matrix in CSC form, with both triangles stored */
+	Q *SparseMatrix
+
+	/* The terms with a nonzero linear coefficient */
+	Linear []LinearTerm
+
+	Constant float64
+}
+
+/* Get the quadratic and linear terms and the constant of the objective, as AMPL's nqpcheck would.
+   Returns an error if the objective isn't quadratic or linear */
+func (o Objective) Quadratic() (*QuadraticFunction, error) {
+	if o.Shape == NonLinear {
+		return nil, fmt.Errorf("Error: Objective %s is not quadratic", o.Name)
+	}
+	x := make([]float64, len(o.p.Variables()))
+	constant, err := o.p.objValue(o.Index, x)
+	if err != nil {
+		return nil, err
+	}
+	grad, err := o.p.objGrad(o.Index, x)
+	if err != nil {
+		return nil, err
+	}
+	q, err := o.p.sparseHessian(x, o.Index, nil)
+	if err != nil {
+		return nil, err
+	}
+	return o.p.quadraticFunction(q, grad, constant), nil
+}
+
+/* Get the quadratic and linear terms and the constant of the body of the constraint, as AMPL's nqpcheck would.
+   The bounds aren't included. Returns an error if the constraint isn't quadratic or linear */
+func (c Constraint) Quadratic() (*QuadraticFunction, error) {
+	if c.Shape == NonLinear {
+		return nil, fmt.Errorf("Error: Constraint %s is not quadratic", c.Name)
+	}
+	x := make([]float64, len(c.p.Variables()))
+	constant, err := c.p.conValue(c.Index, x)
+	if err != nil {
+		return nil, err
+	}
+	grad, err := c.p.conGrad(c.Index, x)
+	if err != nil {
+		return nil, err
+	}
+	/* The Hessian weights the constraint by its Lagrangian scale, which only matches its scale until the
+	   Lagrangian is scaled */
+	multipliers := make([]float64, len(c.p.Constraints()))
+	multipliers[c.Index] = c.p.ConstraintScales()[c.Index] / c.p.LagrangianScales()[c.Index]
+	q, err := c.p.sparseHessian(x, -1, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	return c.p.quadraticFunction(q, grad, constant), nil
+}
+
+/* The Hessian of a quadratic function is Q, and its value and gradient at 0 are the constant and linear terms */
+func (p *Problem) quadraticFunction(q *SparseMatrix, grad []float64, constant float64) *QuadraticFunction {
+	vars := p.Variables()
+	f := &QuadraticFunction{Q: q, Linear: make([]LinearTerm, 0), Constant: constant}
+	for i, coef := range grad {
+		if coef != 0 {
+			f.Linear = append(f.Linear, LinearTerm{Variable: vars[i], Coefficient: coef})
+		}
+	}
+	return f
+}
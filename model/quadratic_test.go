@@ -0,0 +1,59 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+const testQuadraticModelFile = "qp.nl"
+
+/* Get Q, the linear terms and the constant of a quadratic objective */
+func TestObjectiveQuadratic(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testQuadraticModelFile)
+	vars := p.Variables()
+	obj := p.Objectives()[0]
+	assert.Equal(obj.Shape, Quadratic, "Shape")
+	q, err := obj.Quadratic()
+	assert.Nil(err, "No error")
+	assert.Equal(q.Q, &SparseMatrix{3, 3, true, []int{0, 2, 3, 3}, []int{0, 1, 0}, []float64{2, 1, 1}}, "Q")
+	assert.Equal(q.Linear, []LinearTerm{{Variable: vars[2], Coefficient: 3}}, "Linear terms")
+	assert.Equal(q.Constant, 1.0, "Constant")
+}
+
+/* Get Q, the linear terms and the constant of quadratic and linear constraints */
+func TestConstraintQuadratic(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testQuadraticModelFile)
+	vars := p.Variables()
+	cons := p.Constraints()
+	q, err := cons[0].Quadratic()
+	assert.Nil(err, "No error")
+	assert.Equal(q.Q, &SparseMatrix{3, 3, true, []int{0, 1, 3, 3}, []int{1, 0, 1}, []float64{1, 1, 2}}, "Q")
+	assert.Equal(q.Linear, []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[1], Coefficient: -2}}, "Linear terms")
+	assert.Equal(q.Constant, 1.0, "Constant")
+
+	q, err = cons[1].Quadratic()
+	assert.Nil(err, "No error")
+	assert.Equal(q.Q.Start, []int{0, 0, 0, 0}, "Empty Q")
+	assert.Equal(len(q.Q.Values), 0, "Empty Q")
+	assert.Equal(q.Linear, []LinearTerm{{Variable: vars[0], Coefficient: 1}, {Variable: vars[2], Coefficient: 2}}, "Linear terms")
+	assert.Equal(q.Constant, 0.0, "Constant")
+}
+
+/* Nonlinear objectives have no quadratic form, and the Hessian structure is left alone */
+func TestNonLinearQuadratic(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	_, err := p.Objectives()[0].Quadratic()
+	assert.EqualError(err, "Error: Objective _sobj[1] is not quadratic", "Nonlinear objective")
+
+	rows, _, err := p.HessianStructure(0, false, false)
+	assert.Nil(err, "No error")
+	_, err = p.Constraints()[0].Quadratic()
+	assert.Nil(err, "No error")
+	hes := make([]float64, len(rows))
+	assert.Nil(p.HessianValues([]float64{1, 2}, nil, nil, hes), "No error")
+	assert.Equal(hes, []float64{2, 1, math.Exp(2)}, "Hessian structure restored")
+}
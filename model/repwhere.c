@@ -136,9 +136,28 @@ report_where(ASL *asl)
  static void
 jmp_check(Jmp_buf *J, int jv)
 {
-	if (J)
+	if (J) {
 		J->err = jv;
 		__builtin_longjmp(J->jb, 1);
+		}
+	}
+
+ static void
+record_err(ASL *asl, const char *who, real a, real b, int nargs, int jv, const char *msg)
+{
+	EvalErrInfo *E = &asl->i.evalerr_;
+
+	E->who = who;
+	E->a = a;
+	E->b = b;
+	E->nargs = nargs;
+	E->coi = co_index;
+	E->jv = jv;
+	E->msg[0] = 0;
+	if (msg) {
+		strncpy(E->msg, msg, sizeof(E->msg) - 1);
+		E->msg[sizeof(E->msg) - 1] = 0;
+		}
 	}
 
  static void
@@ -224,8 +243,20 @@ deriv_errchk_ASL(ASL *asl, fint *nerror, int coi, int n)
 		return;
 	for(Rp = D->R + k, Rpe = Rp + n; Rp < Rpe; ++Rp, ++coi)
 		if ((R = *Rp)) {
-			jmp_check(err_jmp, R->jv);
 			co_index = coi;
+			if (R->errprint == derrprintf)
+				record_err(asl, R->who, 0., 0., 0, R->jv, R->u.s);
+			else if (R->errprint == derrprint2)
+				record_err(asl, R->who, R->a, R->u.b, 2, R->jv, 0);
+			else
+				record_err(asl, R->who, R->a, 0., 1, R->jv, 0);
+			jmp_check(err_jmp, R->jv);
+			if (nerror && *nerror >= 0) {
+				/* err_jmp was cleared by the nested value call */
+				*nerror = R->jv;
+				return;
+				}
+			jmp_check(err_jmp2, R->jv);
 			report_where(asl);
 			R->errprint(asl,R);
 			fflush(Stderr);
@@ -365,7 +396,9 @@ introuble_ASL(ASL *asl, const char *who, real a, int jv)
 		return;
 		}
 #endif /*}*/
+	record_err(asl, who, a, 0., 1, jv, 0);
 	jmp_check(err_jmp, jv);
+	jmp_check(err_jmp2, jv);
 	report_where(asl);
 	Errprint(fmt, who, a);
 	jmp_check(err_jmp1, jv);
@@ -391,7 +424,9 @@ introuble2_ASL(ASL *asl, const char *who, real a, real b, int jv)
 		return;
 		}
 #endif /*}*/
+	record_err(asl, who, a, b, 2, jv, 0);
 	jmp_check(err_jmp, jv);
+	jmp_check(err_jmp2, jv);
 	report_where(asl);
 	Errprint(fmt, who, a, b);
 	jmp_check(err_jmp1, jv);
@@ -402,7 +437,9 @@ introuble2_ASL(ASL *asl, const char *who, real a, real b, int jv)
 zero_div_ASL(ASL *asl, real L, const char *op)
 {
 	errno_set(EDOM);
+	record_err(asl, op, L, 0., 2, 1, 0);
 	jmp_check(err_jmp, 1);
+	jmp_check(err_jmp2, 1);
 	report_where(asl);
 	fprintf(Stderr, "can't compute %g%s0.\n", L, op);
 	fflush(Stderr);
@@ -449,6 +486,15 @@ fintrouble_ASL(ASL *asl, func_info *fi, const char *s, TMInfo *T)
 		return;
 		}
 #endif /*}*/
+	record_err(asl, fi->name, 0., 0., 0, jv, s);
+	if (err_jmp || err_jmp2) {
+		for(T1 = T->u.prev; T1; T1 = T1prev) {
+			T1prev = T1->u.prev;
+			free(T1);
+			}
+		jmp_check(err_jmp, jv);
+		jmp_check(err_jmp2, jv);
+		}
 	report_where(asl);
 	fprintf(Stderr, fmt, fi->name, s);
 	fflush(Stderr);
@@ -456,7 +502,6 @@ fintrouble_ASL(ASL *asl, func_info *fi, const char *s, TMInfo *T)
 		T1prev = T1->u.prev;
 		free(T1);
 		}
-	jmp_check(err_jmp, jv);
 	jmp_check(err_jmp1,jv);
 	exit(1);
 	}
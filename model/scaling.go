@@ -0,0 +1,290 @@
+package model
+
+/*
+#include "asl.h"
+
+// The vendored ASL evaluates in scaled space when cscale, vscale or lscale are set, but doesn't include
+// conscale.c, so these follow conscale_ASL, varscale_ASL and lagscale_ASL. They return nonzero for a bad index
+
+static real *ones(ASL *asl, int n) {
+	real *x = (real*)M1alloc_ASL(&asl->i, n*sizeof(real));
+	int i;
+
+	for(i = 0; i < n; i++)
+		x[i] = 1.;
+	return x;
+}
+
+// Scale the bounds in L and U by s, swapping them if s is negative
+static void scaleBounds(real *L, real *U, real s) {
+	real t;
+
+	*L *= s;
+	*U *= s;
+	if (s < 0.) {
+		t = *L;
+		*L = *U;
+		*U = t;
+	}
+}
+
+int scaleConstraint(ASL *asl, int i, real s) {
+	real *L, *U;
+	int n = asl->i.n_con_;
+
+	if (i < 0 || i >= n)
+		return 1;
+	if (!asl->i.cscale)
+		asl->i.cscale = ones(asl, n);
+	// The multipliers are scaled with the constraints until lagscale separates them
+	if (!asl->i.lscale)
+		asl->i.lscale = asl->i.cscale;
+	else if (asl->i.lscale != asl->i.cscale)
+		asl->i.lscale[i] *= s;
+	asl->i.cscale[i] *= s;
+	if (asl->i.Urhsx_) {
+		L = asl->i.LUrhs_ + i;
+		U = asl->i.Urhsx_ + i;
+	} else {
+		L = asl->i.LUrhs_ + 2*i;
+		U = L + 1;
+	}
+	scaleBounds(L, U, s);
+	if (asl->i.pi0_)
+		asl->i.pi0_[i] /= s;
+	asl->i.x0kind_ = ASL_first_x;
+	return 0;
+}
+
+int scaleVariable(ASL *asl, int i, real s) {
+	real *L, *U;
+	int n = asl->i.n_var_;
+
+	if (i < 0 || i >= n)
+		return 1;
+	if (!asl->i.vscale)
+		asl->i.vscale = ones(asl, n);
+	asl->i.vscale[i] *= s;
+	if (asl->i.Uvx_) {
+		L = asl->i.LUv_ + i;
+		U = asl->i.Uvx_ + i;
+	} else {
+		L = asl->i.LUv_ + 2*i;
+		U = L + 1;
+	}
+	scaleBounds(L, U, 1. / s);
+	if (asl->i.X0_)
+		asl->i.X0_[i] /= s;
+	asl->i.x0kind_ = ASL_first_x;
+	return 0;
+}
+
+void scaleLagrangian(ASL *asl, real s) {
+	real *l;
+	int i, n = asl->i.n_con_;
+
+	if (!(l = asl->i.lscale) || l == asl->i.cscale) {
+		l = (real*)M1alloc_ASL(&asl->i, n*sizeof(real));
+		for(i = 0; i < n; i++)
+			l[i] = asl->i.cscale ? asl->i.cscale[i] : 1.;
+		asl->i.lscale = l;
+	}
+	for(i = 0; i < n; i++)
+		l[i] *= s;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+/* Scaling
+
+   Once a variable or constraint is scaled the problem is in scaled space: a variable scaled by s has the value
+   x/s, so its bounds, initial value, derivatives and linear and quadratic coefficients change to match, and a
+   constraint scaled by s has the value s*c(x), with its bounds, coefficients and derivatives multiplied by s.
+   Multipliers are scaled with their constraints, and the Lagrangian scale multiplies them all again.
+   `WriteSolution` converts the primal and dual values back before writing them.
+   Scales are multiplied together when a variable or constraint is scaled more than once, and are copied by
+   `Clone`. `WriteOSiL` can't export a scaled problem */
+
+/* Check whether any variable or constraint has a scale other than 1 */
+func (p *Problem) isScaled() bool {
+	for _, s := range append(p.VariableScales(), p.ConstraintScales()...) {
+		if s != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+/* Scale variable `index` by `scale`, which must be nonzero */
+func (p *Problem) ScaleVariable(index int, scale float64) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	if scale == 0 {
+		return fmt.Errorf("Error: Can't scale variable %d by 0", index)
+	}
+	if C.scaleVariable(p.asl, C.int(index), C.real(scale)) != 0 {
+		return fmt.Errorf("Error: Variable index %d out of range", index)
+	}
+	return nil
+}
+
+/* Scale constraint `index` by `scale`, which must be nonzero */
+func (p *Problem) ScaleConstraint(index int, scale float64) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	if scale == 0 {
+		return fmt.Errorf("Error: Can't scale constraint %d by 0", index)
+	}
+	if C.scaleConstraint(p.asl, C.int(index), C.real(scale)) != 0 {
+		return fmt.Errorf("Error: Constraint index %d out of range", index)
+	}
+	return nil
+}
+
+/* Scale the constraint part of the Lagrangian by `scale`, which must be nonzero. This scales the multipliers
+   used by the Hessian computations and written to the `.sol` file */
+func (p *Problem) ScaleLagrangian(scale float64) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	if scale == 0 {
+		return fmt.Errorf("Error: Can't scale the Lagrangian by 0")
+	}
+	C.scaleLagrangian(p.asl, C.real(scale))
+	return nil
+}
+
+/* Get the scale of each variable */
+func (p *Problem) VariableScales() []float64 {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	return scales(p.asl.i.vscale, int(p.asl.i.n_var_))
+}
+
+/* Get the scale of each constraint */
+func (p *Problem) ConstraintScales() []float64 {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	return scales(p.asl.i.cscale, int(p.asl.i.n_con_))
+}
+
+/* Get the scale of each multiplier, including the Lagrangian scale */
+func (p *Problem) LagrangianScales() []float64 {
+	if p.acquire() != nil {
+		return nil
+	}
+	defer p.release()
+	return scales(p.asl.i.lscale, int(p.asl.i.n_con_))
+}
+
+/* Get the scale of variable i. The caller must hold the lock */
+func (p *Problem) variableScale(i int) float64 {
+	if p.asl.i.vscale == nil {
+		return 1
+	}
+	return float64((*[1 << 30]C.real)(unsafe.Pointer(p.asl.i.vscale))[i])
+}
+
+/* Get the scale of constraint i. The caller must hold the lock */
+func (p *Problem) constraintScale(i int) float64 {
+	if p.asl.i.cscale == nil {
+		return 1
+	}
+	return float64((*[1 << 30]C.real)(unsafe.Pointer(p.asl.i.cscale))[i])
+}
+
+/* Get the scale `ScaleLagrangian` applied on top of the constraint scales. The caller must hold the lock */
+func (p *Problem) lagrangianScale() float64 {
+	if p.asl.i.lscale == nil || p.asl.i.lscale == p.asl.i.cscale || p.asl.i.n_con_ == 0 {
+		return 1
+	}
+	return float64(*p.asl.i.lscale) / p.constraintScale(0)
+}
+
+/* Apply the scales of p to a newly loaded copy of it */
+func (p *Problem) copyScales(clone *Problem) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	vscales := scales(p.asl.i.vscale, int(p.asl.i.n_var_))
+	cscales := scales(p.asl.i.cscale, int(p.asl.i.n_con_))
+	lagrangian := p.lagrangianScale()
+	p.release()
+	for i, s := range vscales {
+		if s != 1 {
+			if err := clone.ScaleVariable(i, s); err != nil {
+				return err
+			}
+		}
+	}
+	for i, s := range cscales {
+		if s != 1 {
+			if err := clone.ScaleConstraint(i, s); err != nil {
+				return err
+			}
+		}
+	}
+	if lagrangian != 1 {
+		return clone.ScaleLagrangian(lagrangian)
+	}
+	return nil
+}
+
+/* Copy scale factors out of the ASL structure, which are all 1 until something is scaled */
+func scales(s *C.real, n int) []float64 {
+	vals := make([]float64, n)
+	if s == nil {
+		for i := range vals {
+			vals[i] = 1
+		}
+		return vals
+	}
+	for i, val := range (*[1 << 30]C.real)(unsafe.Pointer(s))[:n:n] {
+		vals[i] = float64(val)
+	}
+	return vals
+}
+
+/* Scale the constraints down so no entry of the Jacobian at x is larger than `maxGradient` in magnitude, like
+   Ipopt's gradient-based scaling. Constraints whose gradients are already small enough aren't scaled.
+   Returns the scale applied to each constraint */
+func (p *Problem) AutoScaleConstraints(x []float64, maxGradient float64) ([]float64, error) {
+	if maxGradient <= 0 {
+		return nil, fmt.Errorf("Error: Maximum gradient must be positive, got %v", maxGradient)
+	}
+	rows, _ := p.JacobianStructure()
+	jac := make([]float64, len(rows))
+	if err := p.JacobianValues(x, jac); err != nil {
+		return nil, err
+	}
+	factors := make([]float64, len(p.Constraints()))
+	rowMax := make([]float64, len(factors))
+	for k, row := range rows {
+		rowMax[row] = math.Max(rowMax[row], math.Abs(jac[k]))
+	}
+	for i := range factors {
+		factors[i] = 1
+		if rowMax[i] > maxGradient {
+			factors[i] = maxGradient / rowMax[i]
+			if err := p.ScaleConstraint(i, factors[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return factors, nil
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+/* Evaluations on a problem with scaled variables run in scaled space */
+func TestScaleVariable(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Equal(p.VariableScales(), []float64{1, 1}, "No scaling")
+	assert.Nil(p.ScaleVariable(1, 2), "No error")
+	assert.Equal(p.VariableScales(), []float64{1, 2}, "Variable scales")
+
+	obj := p.Objectives()[0]
+	val, err := obj.Value([]float64{1, 1})
+	assert.Nil(err, "No error")
+	assert.InDelta(val, 3+math.Exp(2), 1e-12, "Objective value")
+	grad, err := obj.Gradient([]float64{1, 1})
+	assert.Nil(err, "No error")
+	assert.InDeltaSlice(grad, []float64{4, 2 + 2*math.Exp(2)}, 1e-12, "Objective gradient")
+	hes, err := p.FullHessian([]float64{1, 1}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.InDeltaSlice(hes, []float64{2, 2, 2, 4 * math.Exp(2)}, 1e-12, "Objective Hessian")
+
+	x, _ := p.InitialPrimal()
+	assert.Equal(x, []float64{1, 1}, "Scaled initial point")
+	vars := p.Variables()
+	assert.Equal(vars[1].LowerBound, -5.0, "Scaled lower bound")
+	assert.Equal(vars[1].UpperBound, 5.0, "Scaled upper bound")
+}
+
+/* Scaling a constraint scales its value and bounds, and a negative scale flips its sense */
+func TestScaleConstraint(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Nil(p.ScaleConstraint(0, 0.5), "No error")
+	assert.Nil(p.ScaleConstraint(1, -1), "No error")
+	assert.Equal(p.ConstraintScales(), []float64{0.5, -1}, "Constraint scales")
+	assert.Equal(p.LagrangianScales(), []float64{0.5, -1}, "Multipliers scale with the constraints")
+
+	vals, err := p.ConstraintValues([]float64{1, 2})
+	assert.Nil(err, "No error")
+	assert.Equal(vals, []float64{2.5, -5}, "Scaled values")
+	cons := p.Constraints()
+	assert.Equal(cons[0].Max, 2.0, "Scaled bound")
+	assert.Equal(cons[1].Sense, ConstraintLessThan, "Flipped sense")
+	assert.Equal(cons[1].Max, -1.0, "Flipped bound")
+
+	assert.Nil(p.ScaleLagrangian(2), "No error")
+	assert.Equal(p.ConstraintScales(), []float64{0.5, -1}, "Constraint scales")
+	assert.Equal(p.LagrangianScales(), []float64{1, -2}, "Lagrangian scales")
+	hes, err := p.FullHessian([]float64{1, 2}, -1, nil, []float64{1, 0})
+	assert.Nil(err, "No error")
+	assert.Equal(hes, []float64{2, 0, 0, 2}, "Scaled constraint Hessian")
+
+	assert.NotNil(p.ScaleConstraint(2, 1), "Index out of range")
+	assert.NotNil(p.ScaleVariable(0, 0), "Zero scale")
+	assert.NotNil(p.ScaleLagrangian(0), "Zero scale")
+}
+
+/* The value and gradient of a single scaled constraint match the values and Jacobian of all of them */
+func TestScaledConstraintEvaluation(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Nil(p.ScaleVariable(1, 2), "No error")
+	assert.Nil(p.ScaleConstraint(1, 10), "No error")
+	x := []float64{1, 1}
+	vals, err := p.ConstraintValues(x)
+	assert.Nil(err, "No error")
+	rows, cols := p.JacobianStructure()
+	jac := make([]float64, len(rows))
+	assert.Nil(p.JacobianValues(x, jac), "No error")
+	for i, c := range p.Constraints() {
+		val, err := c.Value(x)
+		assert.Nil(err, "No error")
+		assert.Equal(val, vals[i], "Constraint value")
+		assert.True(c.IsSatisfied(val) == (val >= c.Min-Featol && val <= c.Max+Featol), "Satisfied in scaled space")
+		grad, err := c.Gradient(x)
+		assert.Nil(err, "No error")
+		for k := range rows {
+			if rows[k] == i {
+				assert.Equal(grad[cols[k]], jac[k], "Constraint gradient")
+			}
+		}
+	}
+}
+
+/* Linear coefficients and quadratic terms are in scaled space, like the bounds */
+func TestScaledCoefficients(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	assert.Nil(p.ScaleVariable(2, 4), "No error")
+	assert.Nil(p.ScaleConstraint(0, 0.5), "No error")
+	x := make([]float64, 9)
+	x[2] = 1
+	rows, cols := p.JacobianStructure()
+	jac := make([]float64, len(rows))
+	assert.Nil(p.JacobianValues(x, jac), "No error")
+	m := p.LinearMatrixCSR()
+	for k := range rows {
+		found := false
+		for e := m.Start[rows[k]]; e < m.Start[rows[k]+1]; e++ {
+			if m.Index[e] == cols[k] {
+				assert.Equal(m.Values[e], jac[k], "Matrix coefficient")
+				found = true
+			}
+		}
+		assert.True(found, "Matrix entry")
+	}
+	for i, c := range p.Constraints() {
+		for _, term := range c.LinearTerms() {
+			for k := range rows {
+				if rows[k] == i && cols[k] == term.Variable.Index {
+					assert.Equal(term.Coefficient, jac[k], "Linear term")
+				}
+			}
+		}
+	}
+	grad, err := p.Objectives()[0].Gradient(x)
+	assert.Nil(err, "No error")
+	for _, term := range p.Objectives()[0].LinearTerms() {
+		assert.Equal(term.Coefficient, grad[term.Variable.Index], "Objective linear term")
+	}
+
+	p = ProblemFromFile(testQuadraticModelFile)
+	assert.Nil(p.ScaleVariable(1, 2), "No error")
+	assert.Nil(p.ScaleConstraint(0, 3), "No error")
+	assert.Nil(p.ScaleLagrangian(5), "No error")
+	x = []float64{1, 2, 3}
+	for _, c := range p.Constraints() {
+		f, err := c.Quadratic()
+		assert.Nil(err, "No error")
+		val, err := c.Value(x)
+		assert.Nil(err, "No error")
+		assert.InDelta(quadraticValue(f, x), val, 1e-9, "Scaled quadratic constraint")
+	}
+	f, err := p.Objectives()[0].Quadratic()
+	assert.Nil(err, "No error")
+	val, err := p.Objectives()[0].Value(x)
+	assert.Nil(err, "No error")
+	assert.InDelta(quadraticValue(f, x), val, 1e-9, "Scaled quadratic objective")
+}
+
+/* Evaluate a quadratic function at x */
+func quadraticValue(f *QuadraticFunction, x []float64) float64 {
+	val := f.Constant
+	for _, term := range f.Linear {
+		val += term.Coefficient * x[term.Variable.Index]
+	}
+	for j := 0; j < f.Q.NumCols; j++ {
+		for k := f.Q.Start[j]; k < f.Q.Start[j+1]; k++ {
+			val += 0.5 * f.Q.Values[k] * x[f.Q.Index[k]] * x[j]
+		}
+	}
+	return val
+}
+
+/* A clone is scaled like the problem it was cloned from */
+func TestCloneScales(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	assert.Nil(p.ScaleVariable(1, 2), "No error")
+	assert.Nil(p.ScaleConstraint(0, 0.5), "No error")
+	assert.Nil(p.ScaleLagrangian(3), "No error")
+	clone, err := p.Clone()
+	assert.Nil(err, "No error")
+	defer clone.Close()
+	assert.Equal(clone.VariableScales(), p.VariableScales(), "Variable scales")
+	assert.Equal(clone.ConstraintScales(), p.ConstraintScales(), "Constraint scales")
+	assert.Equal(clone.LagrangianScales(), p.LagrangianScales(), "Lagrangian scales")
+	assert.Equal(clone.Variables(), p.Variables(), "Scaled bounds")
+}
+
+/* Scale the diet constraints so no Jacobian entry is larger than 100 */
+func TestAutoScaleConstraints(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testModelFile)
+	x := make([]float64, 9)
+	factors, err := p.AutoScaleConstraints(x, 100)
+	assert.Nil(err, "No error")
+	assert.InDelta(factors[0], 100.0/510, 1e-12, "Scaled constraint")
+	assert.Equal(factors, p.ConstraintScales(), "Applied scales")
+	jac := make([]float64, p.JacobianNonZeros())
+	assert.Nil(p.JacobianValues(x, jac), "No error")
+	for _, val := range jac {
+		assert.True(math.Abs(val) <= 100+1e-9, "Jacobian entry %v", val)
+	}
+	_, err = p.AutoScaleConstraints(x, 0)
+	assert.NotNil(err, "Bad maximum")
+}
@@ -1,24 +1,14 @@
 package model
 
-type Shape int
+import (
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+type Shape = types.Shape
 
 const (
-	Constant Shape = iota
-	Linear
-	Quadratic
-	NonLinear
+	Constant  = types.Constant
+	Linear    = types.Linear
+	Quadratic = types.Quadratic
+	NonLinear = types.NonLinear
 )
-
-func (c Shape) String() string {
-	switch c {
-	case Constant:
-		return "Constant"
-	case Linear:
-		return "Linear"
-	case Quadratic:
-		return "Quadratic"
-	case NonLinear:
-		return "Non-Linear"
-	}
-	return "Unknown"
-}
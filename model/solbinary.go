@@ -0,0 +1,302 @@
+package model
+
+/*
+#include "asl.h"
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+/* Binary `.sol` files are written by `write_solf_ASL` when the `.nl` file was binary. They're a series of
+   records, each framed by its length before and after, in native byte order:
+   "binary", the message, an empty record if the message isn't empty, the options, the dual values and the
+   primal values, then the objective number with the solve_result_num and a record for each suffix. The
+   lengths of the options and suffix records don't always match what's written, so those are read by their
+   contents */
+
+/* The size of the integers in a binary `.sol` file */
+const solIntSize = int(unsafe.Sizeof(C.fint(0)))
+
+/* Check whether the data starts with the "binary" record of a binary `.sol` file */
+func isBinarySolution(data []byte) bool {
+	r := &solBinaryReader{data: data, order: nativeByteOrder()}
+	rec, err := r.record()
+	return err == nil && string(rec) == "binary"
+}
+
+/* Read a binary `.sol` file into sol, for a problem of the given size */
+func readBinarySolution(path string, data []byte, sol *Solution, sizes []int) (*Solution, error) {
+	numVariables, numConstraints := sizes[SuffixVariable], sizes[SuffixConstraint]
+	r := &solBinaryReader{path: path, data: data, order: nativeByteOrder()}
+	if _, err := r.record(); err != nil {
+		return nil, err
+	}
+	message, err := r.record()
+	if err != nil {
+		return nil, err
+	}
+	if len(message) > 0 {
+		if _, err := r.record(); err != nil {
+			return nil, err
+		}
+	}
+	sol.Message = string(bytes.TrimRight(message, "\n"))
+
+	if r.hasOptions() {
+		if err := r.readOptions(sol, numConstraints, numVariables); err != nil {
+			return nil, err
+		}
+	}
+	dual, err := r.values()
+	if err != nil {
+		return nil, err
+	}
+	primal, err := r.values()
+	if err != nil {
+		return nil, err
+	}
+	if (len(dual) != 0 && len(dual) != numConstraints) || (len(primal) != 0 && len(primal) != numVariables) {
+		return nil, r.errorf("solution has %d dual and %d primal values, expected %d and %d", len(dual), len(primal), numConstraints, numVariables)
+	}
+	if len(dual) > 0 {
+		sol.Dual = dual
+	}
+	if len(primal) > 0 {
+		sol.Primal = primal
+	}
+
+	if r.done() {
+		return sol, nil
+	}
+	rec, err := r.record()
+	if err != nil {
+		return nil, err
+	}
+	if len(rec) != solIntSize && len(rec) != 2*solIntSize {
+		return nil, r.errorf("bad objno record of %d bytes", len(rec))
+	}
+	sol.Objective = r.intAt(rec, 0) - 1
+	if len(rec) == 2*solIntSize {
+		sol.SolveResultNum = r.intAt(rec, 1)
+	}
+	for !r.done() {
+		suf, err := r.readSuffix(sizes)
+		if err != nil {
+			return nil, err
+		}
+		sol.Suffixes = append(sol.Suffixes, suf)
+	}
+	return sol, nil
+}
+
+/* Get the byte order of this machine, which binary `.sol` files are written in */
+func nativeByteOrder() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+/* Reads the records of a binary `.sol` file, keeping track of the offset for errors */
+type solBinaryReader struct {
+	path  string
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *solBinaryReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Error reading %q at byte %d: %s", r.path, r.pos, fmt.Sprintf(format, args...))
+}
+
+func (r *solBinaryReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *solBinaryReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data)-r.pos {
+		return nil, r.errorf("premature end of file")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+/* Get integer i of b */
+func (r *solBinaryReader) intAt(b []byte, i int) int {
+	b = b[i*solIntSize : (i+1)*solIntSize]
+	if solIntSize == 4 {
+		return int(int32(r.order.Uint32(b)))
+	}
+	return int(int64(r.order.Uint64(b)))
+}
+
+func (r *solBinaryReader) int() (int, error) {
+	b, err := r.bytes(solIntSize)
+	if err != nil {
+		return 0, err
+	}
+	return r.intAt(b, 0), nil
+}
+
+func (r *solBinaryReader) float() (float64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(r.order.Uint64(b)), nil
+}
+
+/* Read a record, checking the lengths before and after it match */
+func (r *solBinaryReader) record() ([]byte, error) {
+	n, err := r.int()
+	if err != nil {
+		return nil, err
+	}
+	rec, err := r.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	if end, err := r.int(); err != nil {
+		return nil, err
+	} else if end != n {
+		return nil, r.errorf("record length %d doesn't match %d", end, n)
+	}
+	return rec, nil
+}
+
+/* Read a record of values */
+func (r *solBinaryReader) values() ([]float64, error) {
+	rec, err := r.record()
+	if err != nil {
+		return nil, err
+	}
+	if len(rec)%8 != 0 {
+		return nil, r.errorf("bad record of values of %d bytes", len(rec))
+	}
+	vals := make([]float64, len(rec)/8)
+	for i := range vals {
+		vals[i] = math.Float64frombits(r.order.Uint64(rec[i*8:]))
+	}
+	return vals, nil
+}
+
+/* Check whether the next record holds the options */
+func (r *solBinaryReader) hasOptions() bool {
+	start := r.pos + solIntSize
+	return start+7 <= len(r.data) && string(r.data[start:start+7]) == "Options"
+}
+
+/* Read the options record, with the number of constraints and variables and of dual and primal values */
+func (r *solBinaryReader) readOptions(sol *Solution, numConstraints int, numVariables int) error {
+	if _, err := r.bytes(solIntSize + 7); err != nil {
+		return err
+	}
+	numOptions, err := r.int()
+	if err != nil {
+		return err
+	}
+	if numOptions < 0 || numOptions > 100 {
+		return r.errorf("bad number of options %d", numOptions)
+	}
+	hasVbtol := false
+	for i := 0; i < numOptions; i++ {
+		opt, err := r.int()
+		if err != nil {
+			return err
+		}
+		sol.Options = append(sol.Options, opt)
+		// AMPL counts the vbtol value as two extra options
+		if i == 1 && opt == 3 {
+			hasVbtol = true
+			numOptions -= 2
+		}
+	}
+	counts := make([]int, 4)
+	for i := range counts {
+		if counts[i], err = r.int(); err != nil {
+			return err
+		}
+	}
+	if counts[0] != numConstraints || counts[2] != numVariables {
+		return r.errorf("solution has %d constraints and %d variables, expected %d and %d", counts[0], counts[2], numConstraints, numVariables)
+	}
+	if hasVbtol {
+		if _, err := r.float(); err != nil {
+			return err
+		}
+	}
+	_, err = r.int()
+	return err
+}
+
+/* Read a suffix record: its kind, number of values and the lengths of its name and table, then the name, the
+   table and the index and value of each nonzero */
+func (r *solBinaryReader) readSuffix(sizes []int) (*Suffix, error) {
+	if _, err := r.int(); err != nil {
+		return nil, err
+	}
+	id, err := r.bytes(8)
+	if err != nil {
+		return nil, err
+	}
+	if string(id) != "\nSuffix\n" {
+		return nil, r.errorf("expected a suffix")
+	}
+	var nums [4]int
+	for i := range nums {
+		if nums[i], err = r.int(); err != nil {
+			return nil, err
+		}
+		if nums[i] < 0 {
+			return nil, r.errorf("bad suffix header")
+		}
+	}
+	kind, numValues := nums[0], nums[1]
+	name, err := r.bytes(nums[2])
+	if err != nil {
+		return nil, err
+	}
+	table, err := r.bytes(nums[3])
+	if err != nil {
+		return nil, err
+	}
+	suf := &Suffix{
+		Name:   string(bytes.TrimRight(name, "\x00")),
+		Kind:   SuffixKind(kind & suffixKindMask),
+		Real:   kind&suffixKindReal != 0,
+		InOut:  kind&suffixKindIODcl != 0,
+		Table:  string(bytes.TrimRight(table, "\x00")),
+		Values: make(map[int]float64, numValues),
+	}
+	for i := 0; i < numValues; i++ {
+		index, err := r.int()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= sizes[suf.Kind] {
+			return nil, r.errorf("bad index %d for suffix %s", index, suf.Name)
+		}
+		var val float64
+		if suf.Real {
+			val, err = r.float()
+		} else {
+			var v int
+			v, err = r.int()
+			val = float64(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		suf.Values[index] = val
+	}
+	_, err = r.int()
+	return suf, err
+}
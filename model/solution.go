@@ -0,0 +1,511 @@
+package model
+
+/*
+#include "getstub.h"
+#include <stdlib.h>
+#include <string.h>
+
+// Write a .sol file with the `nsuf` suffixes in `suf` instead of the ones declared for the problem.
+// Returns nonzero if the file couldn't be written
+int writeSolution(ASL *asl, const char *msg, real *x, real *y, SufDesc *suf, int nsuf, const char *fname) {
+	Option_Info oi;
+	SufDesc *saved[4];
+	int flags, i, k, rv;
+
+	cur_ASL = asl;
+	memcpy(saved, asl->i.suffixes, sizeof(saved));
+	memset(asl->i.suffixes, 0, sizeof(saved));
+	for(i = nsuf; --i >= 0; ) {
+		k = suf[i].kind & ASL_Sufkind_mask;
+		suf[i].next = asl->i.suffixes[k];
+		asl->i.suffixes[k] = suf + i;
+	}
+	// Write the solve_result_num and suffixes, but don't print the message
+	flags = asl->i.flags;
+	asl->i.flags |= 1;
+	memset(&oi, 0, sizeof(oi));
+	oi.wantsol = 9;
+	rv = write_solf_ASL(asl, msg, x, y, &oi, fname);
+	asl->i.flags = flags;
+	memcpy(asl->i.suffixes, saved, sizeof(saved));
+	return rv;
+}
+*/
+import "C"
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+/* The result of solving a problem, as read from a `.sol` file */
+type Solution struct {
+	/* The message printed by the solver */
+	Message string
+
+	/* The options AMPL passed to the solver, as echoed back in the file */
+	Options []int
+
+	/* The values of the variables, or nil if the solver didn't return them */
+	Primal []float64
+
+	/* The dual values of the constraints, or nil if the solver didn't return them */
+	Dual []float64
+
+	/* The index of the objective the solver used, or -1 if it isn't known */
+	Objective int
+
+	/* The solve_result_num reported by the solver, or -1 if it isn't known */
+	SolveResultNum int
+
+	/* The suffixes returned by the solver */
+	Suffixes []*Suffix
+
+	p *Problem
+}
+
+/* Get the value of variable `v` in the solution. Returns 0 if the solver didn't return primal values */
+func (s *Solution) VariableValue(v Variable) float64 {
+	if v.Index < 0 || v.Index >= len(s.Primal) {
+		return 0
+	}
+	return s.Primal[v.Index]
+}
+
+/* Get the dual value of constraint `c` in the solution. Returns 0 if the solver didn't return dual values */
+func (s *Solution) ConstraintDual(c Constraint) float64 {
+	if c.Index < 0 || c.Index >= len(s.Dual) {
+		return 0
+	}
+	return s.Dual[c.Index]
+}
+
+/* Find a suffix returned by the solver, or nil if there isn't one with this name and kind */
+func (s *Solution) Suffix(name string, kind SuffixKind) *Suffix {
+	for _, suf := range s.Suffixes {
+		if suf.Name == name && suf.Kind == kind {
+			return suf
+		}
+	}
+	return nil
+}
+
+/* Describe the solve result in the same terms as AMPL's solve_result */
+func (s *Solution) SolveResult() string {
+	switch {
+	case s.SolveResultNum < 0:
+		return "?"
+	case s.SolveResultNum < 100:
+		return "solved"
+	case s.SolveResultNum < 200:
+		return "solved?"
+	case s.SolveResultNum < 300:
+		return "infeasible"
+	case s.SolveResultNum < 400:
+		return "unbounded"
+	case s.SolveResultNum < 500:
+		return "limit"
+	case s.SolveResultNum < 600:
+		return "failure"
+	}
+	return "?"
+}
+
+/* The path of the `.sol` file for this problem, next to the `.nl` file it was read from */
+func (p *Problem) solutionPath() string {
+	return strings.TrimSuffix(p.path, ".nl") + ".sol"
+}
+
+/* Read the `.sol` file a solver wrote for this problem. If `path` is empty the file is read from next
+   to the `.nl` file, e.g. `diet.sol` for `diet.nl`. Both text `.sol` files and the binary ones written for
+   binary `.nl` files are read */
+func (p *Problem) ReadSolution(path string) (*Solution, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	numVariables := int(p.asl.i.n_var_)
+	numConstraints := int(p.asl.i.n_con_)
+	numObjectives := int(p.asl.i.n_obj_)
+	p.release()
+
+	if path == "" {
+		path = p.solutionPath()
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Unable to read %q: %v", path, err)
+	}
+	sol := &Solution{Objective: -1, SolveResultNum: -1, p: p}
+	sizes := []int{numVariables, numConstraints, numObjectives, 1}
+	if isBinarySolution(data) {
+		return readBinarySolution(path, data, sol, sizes)
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("Error reading %q: not a text or binary .sol file", path)
+	}
+	r := &solReader{path: path, scanner: bufio.NewScanner(bytes.NewReader(data))}
+
+	/* The message ends with an empty line. Empty lines in the message are written as a single space */
+	var message []string
+	for {
+		line, ok := r.next()
+		if !ok {
+			return nil, r.errorf("missing end of the solver message")
+		}
+		if line == "" {
+			break
+		}
+		message = append(message, strings.TrimRight(line, " "))
+	}
+	sol.Message = strings.Join(message, "\n")
+
+	line, ok := r.next()
+	var values []float64
+	numDual, numPrimal := -1, -1
+	if ok && line == "Options" {
+		numOptions, err := r.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		if numOptions < 0 || numOptions > 100 {
+			return nil, r.errorf("bad number of options %d", numOptions)
+		}
+		hasVbtol := false
+		for i := 0; i < numOptions; i++ {
+			opt, err := r.nextInt()
+			if err != nil {
+				return nil, err
+			}
+			sol.Options = append(sol.Options, opt)
+			// AMPL counts the vbtol value as two extra options
+			if i == 1 && opt == 3 {
+				hasVbtol = true
+				numOptions -= 2
+			}
+		}
+		counts := make([]int, 4)
+		for i := range counts {
+			if counts[i], err = r.nextInt(); err != nil {
+				return nil, err
+			}
+		}
+		if counts[0] != numConstraints || counts[2] != numVariables {
+			return nil, r.errorf("solution has %d constraints and %d variables, expected %d and %d", counts[0], counts[2], numConstraints, numVariables)
+		}
+		numDual, numPrimal = counts[1], counts[3]
+		if (numDual != 0 && numDual != numConstraints) || (numPrimal != 0 && numPrimal != numVariables) {
+			return nil, r.errorf("solution has %d dual and %d primal values", numDual, numPrimal)
+		}
+		if hasVbtol {
+			if _, err := r.nextFloat(); err != nil {
+				return nil, err
+			}
+		}
+		line, ok = r.next()
+	}
+
+	/* Without the options the number of values has to be worked out from the size of the problem */
+	for ok && !strings.HasPrefix(line, "objno") && !strings.HasPrefix(line, "suffix") && (numDual < 0 || len(values) < numDual+numPrimal) {
+		val, err := r.parseFloat(line)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+		line, ok = r.next()
+	}
+	if numDual < 0 {
+		switch len(values) {
+		case numConstraints + numVariables:
+			numDual, numPrimal = numConstraints, numVariables
+		case numVariables:
+			numDual, numPrimal = 0, numVariables
+		case 0:
+			numDual, numPrimal = 0, 0
+		default:
+			return nil, r.errorf("solution has %d values, expected %d", len(values), numConstraints+numVariables)
+		}
+	}
+	if len(values) != numDual+numPrimal {
+		return nil, r.errorf("premature end of file")
+	}
+	if numDual > 0 {
+		sol.Dual = values[:numDual]
+	}
+	if numPrimal > 0 {
+		sol.Primal = values[numDual:]
+	}
+
+	if ok && strings.HasPrefix(line, "objno") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, r.errorf("bad objno line %q", line)
+		}
+		objno, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, r.errorf("bad objno line %q", line)
+		}
+		sol.Objective = objno - 1
+		if len(fields) == 3 {
+			if sol.SolveResultNum, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, r.errorf("bad objno line %q", line)
+			}
+		}
+		line, ok = r.next()
+	}
+
+	for ; ok; line, ok = r.next() {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		suf, err := r.readSuffix(line, sizes)
+		if err != nil {
+			return nil, err
+		}
+		sol.Suffixes = append(sol.Suffixes, suf)
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error: Unable to read %q: %v", path, err)
+	}
+	return sol, nil
+}
+
+/* Set the solve_result_num written to the `.sol` file by `WriteSolution`. AMPL groups the codes as
+   0-99 solved, 100-199 solved?, 200-299 infeasible, 300-399 unbounded, 400-499 limit and 500-599 failure */
+func (p *Problem) SetSolveResultNum(num int) {
+	if p.acquire() != nil {
+		return
+	}
+	defer p.release()
+	p.asl.p.solve_code_ = C.int(num)
+}
+
+/* Write a `.sol` file next to the `.nl` file, the way an AMPL solver returns its results. `x` has the values of
+   the variables and `y` the dual values of the constraints; either can be nil if there are none to return.
+   The suffixes are returned to AMPL along with any attached with `SetSuffix`, which they replace if the names
+   and kinds match. The message isn't printed, solvers usually print it to stdout as well */
+func (p *Problem) WriteSolution(message string, x []float64, y []float64, suffixes []*Suffix) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	numVariables := int(p.asl.i.n_var_)
+	numConstraints := int(p.asl.i.n_con_)
+	var xC, yC *C.real
+	if x != nil {
+		if len(x) != numVariables {
+			return fmt.Errorf("Error: Incorrect number of variables in solution: expected %d, got %d", numVariables, len(x))
+		}
+		if numVariables > 0 {
+			xC = (*C.real)(unsafe.Pointer(&x[0]))
+		}
+	}
+	if y != nil {
+		if len(y) != numConstraints {
+			return fmt.Errorf("Error: Incorrect number of dual values in solution: expected %d, got %d", numConstraints, len(y))
+		}
+		if numConstraints > 0 {
+			yC = (*C.real)(unsafe.Pointer(&y[0]))
+		}
+	}
+
+	// Suffixes passed in replace the ones attached with SetSuffix
+	all := append([]*Suffix{}, suffixes...)
+	for _, suf := range p.outSuffixes {
+		replaced := false
+		for _, s := range suffixes {
+			replaced = replaced || (s.Name == suf.Name && s.Kind == suf.Kind)
+		}
+		if !replaced {
+			all = append(all, suf)
+		}
+	}
+	sizes := []int{numVariables, numConstraints, int(p.asl.i.n_obj_), 1}
+	sufList, err := cSuffixes(all, sizes)
+	if err != nil {
+		return err
+	}
+	defer freeCSuffixes(sufList)
+	var sufPtr *C.SufDesc
+	if len(sufList) > 0 {
+		sufPtr = &sufList[0]
+	}
+
+	path := p.solutionPath()
+	messageC := C.CString(message)
+	defer C.free(unsafe.Pointer(messageC))
+	pathC := C.CString(path)
+	defer C.free(unsafe.Pointer(pathC))
+	if C.writeSolution(p.asl, messageC, xC, yC, sufPtr, C.int(len(sufList)), pathC) != 0 {
+		return fmt.Errorf("Error: Unable to write %q", path)
+	}
+	return nil
+}
+
+/* Convert suffixes to an array of SufDesc in C memory, which must be freed with `freeCSuffixes` */
+func cSuffixes(suffixes []*Suffix, sizes []int) ([]C.SufDesc, error) {
+	if len(suffixes) == 0 {
+		return nil, nil
+	}
+	for _, suf := range suffixes {
+		if suf.Kind < SuffixVariable || suf.Kind > SuffixProblem {
+			return nil, fmt.Errorf("Error: Unknown kind of suffix %s: %d", suf.Name, suf.Kind)
+		}
+		for index := range suf.Values {
+			if index < 0 || index >= sizes[suf.Kind] {
+				return nil, fmt.Errorf("Error: Index %d out of range for suffix %s", index, suf.Name)
+			}
+		}
+	}
+	ptr := (*C.SufDesc)(C.calloc(C.size_t(len(suffixes)), C.size_t(unsafe.Sizeof(C.SufDesc{}))))
+	list := (*[1 << 30]C.SufDesc)(unsafe.Pointer(ptr))[:len(suffixes):len(suffixes)]
+	for i, suf := range suffixes {
+		d := &list[i]
+		n := sizes[suf.Kind]
+		d.sufname = C.CString(suf.Name)
+		if suf.Table != "" {
+			d.table = C.CString(suf.Table)
+		}
+		d.kind = C.int(suf.Kind) | C.ASL_Sufkind_output
+		if suf.InOut {
+			d.kind |= C.ASL_Sufkind_iodcl
+		}
+		if suf.Real {
+			d.kind |= C.ASL_Sufkind_real
+			d.u.r = (*C.real)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(C.real(0)))))
+			vals := (*[1 << 30]C.real)(unsafe.Pointer(d.u.r))[:n:n]
+			for index, val := range suf.Values {
+				vals[index] = C.real(val)
+			}
+		} else {
+			d.u.i = (*C.int)(C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(C.int(0)))))
+			vals := (*[1 << 30]C.int)(unsafe.Pointer(d.u.i))[:n:n]
+			for index, val := range suf.Values {
+				vals[index] = C.int(val)
+			}
+		}
+	}
+	return list, nil
+}
+
+func freeCSuffixes(list []C.SufDesc) {
+	if len(list) == 0 {
+		return
+	}
+	for i := range list {
+		C.free(unsafe.Pointer(list[i].sufname))
+		C.free(unsafe.Pointer(list[i].table))
+		C.free(unsafe.Pointer(list[i].u.r))
+		C.free(unsafe.Pointer(list[i].u.i))
+	}
+	C.free(unsafe.Pointer(&list[0]))
+}
+
+/* Reads a `.sol` file line by line, keeping track of the line number for errors */
+type solReader struct {
+	path    string
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *solReader) next() (string, bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.line++
+	return strings.TrimRight(r.scanner.Text(), "\r"), true
+}
+
+func (r *solReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Error reading %q at line %d: %s", r.path, r.line, fmt.Sprintf(format, args...))
+}
+
+func (r *solReader) nextInt() (int, error) {
+	line, ok := r.next()
+	if !ok {
+		return 0, r.errorf("premature end of file")
+	}
+	val, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, r.errorf("expected an integer, got %q", line)
+	}
+	return val, nil
+}
+
+func (r *solReader) nextFloat() (float64, error) {
+	line, ok := r.next()
+	if !ok {
+		return 0, r.errorf("premature end of file")
+	}
+	return r.parseFloat(line)
+}
+
+func (r *solReader) parseFloat(line string) (float64, error) {
+	val, err := strconv.ParseFloat(strings.TrimSpace(line), 64)
+	if err != nil {
+		return 0, r.errorf("expected a number, got %q", line)
+	}
+	return val, nil
+}
+
+/* Read a suffix section, starting from its header line:
+   suffix <kind> <number of values> <name length> <table length> <table lines> */
+func (r *solReader) readSuffix(header string, sizes []int) (*Suffix, error) {
+	fields := strings.Fields(header)
+	if len(fields) != 6 || fields[0] != "suffix" {
+		return nil, r.errorf("expected a suffix, got %q", header)
+	}
+	var nums [5]int
+	for i := range nums {
+		val, err := strconv.Atoi(fields[i+1])
+		if err != nil || val < 0 {
+			return nil, r.errorf("bad suffix header %q", header)
+		}
+		nums[i] = val
+	}
+	kind, numValues, tableLines := nums[0], nums[1], nums[4]
+	name, ok := r.next()
+	if !ok {
+		return nil, r.errorf("premature end of file")
+	}
+	suf := &Suffix{
+		Name:   strings.TrimSpace(name),
+		Kind:   SuffixKind(kind & suffixKindMask),
+		Real:   kind&suffixKindReal != 0,
+		InOut:  kind&suffixKindIODcl != 0,
+		Values: make(map[int]float64, numValues),
+	}
+	var table []string
+	for i := 0; i < tableLines; i++ {
+		line, ok := r.next()
+		if !ok {
+			return nil, r.errorf("premature end of file")
+		}
+		table = append(table, line)
+	}
+	suf.Table = strings.Join(table, "\n")
+	for i := 0; i < numValues; i++ {
+		line, ok := r.next()
+		if !ok {
+			return nil, r.errorf("premature end of file")
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return nil, r.errorf("bad value for suffix %s: %q", suf.Name, line)
+		}
+		index, err := strconv.Atoi(parts[0])
+		if err != nil || index < 0 || index >= sizes[suf.Kind] {
+			return nil, r.errorf("bad index for suffix %s: %q", suf.Name, line)
+		}
+		val, err := r.parseFloat(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		suf.Values[index] = val
+	}
+	return suf, nil
+}
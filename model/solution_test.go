@@ -0,0 +1,222 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* Read a solution with options, duals and suffixes for the nonlinear problem */
+func TestReadSolution(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testNonLinearModelFile)
+	defer p.Close()
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "MINOS 5.51: optimal solution found.\n3 iterations, objective 0.8347", "Message")
+	assert.Equal(sol.Options, []int{1, 1, 0}, "Options")
+	assert.Equal(sol.Dual, []float64{0, 0.5}, "Dual values")
+	assert.Equal(sol.Primal, []float64{-0.5, 0.75}, "Primal values")
+	assert.Equal(sol.Objective, 0, "Objective")
+	assert.Equal(sol.SolveResultNum, 0, "Solve result")
+	assert.Equal(sol.SolveResult(), "solved", "Solve result")
+	assert.Equal(len(sol.Suffixes), 3, "Suffixes")
+
+	vars := p.Variables()
+	cons := p.Constraints()
+	assert.Equal(sol.VariableValue(vars[1]), 0.75, "Variable value")
+	assert.Equal(sol.ConstraintDual(cons[1]), 0.5, "Constraint dual")
+
+	sstatus := sol.Suffix("sstatus", SuffixVariable)
+	assert.Equal(sstatus, &Suffix{Name: "sstatus", Kind: SuffixVariable, Values: map[int]float64{0: 1, 1: 3}}, "sstatus")
+	slack := sol.Suffix("slack", SuffixConstraint)
+	assert.True(slack.Real, "Real suffix")
+	assert.Equal(slack.Value(0), float64(0), "Default value")
+	assert.Equal(slack.Value(1), 0.25, "slack")
+	status := sol.Suffix("status", SuffixProblem)
+	assert.Equal(status.Table, "0\tgood\tall is well\n1\tbad", "Table")
+	assert.Equal(status.Value(0), float64(1), "status")
+	assert.Nil(sol.Suffix("sstatus", SuffixObjective), "Missing suffix")
+}
+
+/* Write `contents` to a temporary `.sol` file and read it for the nonlinear problem */
+func readTempSolution(t *testing.T, contents string) (*Solution, error) {
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hs1.sol")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := ProblemFromFile(testNonLinearModelFile)
+	defer p.Close()
+	return p.ReadSolution(path)
+}
+
+/* Old solvers don't write the options, so the values are split using the size of the problem */
+func TestReadSolutionWithoutOptions(t *testing.T) {
+	assert := assert.New(t)
+	sol, err := readTempSolution(t, "solved\n \nagain\n\n1\n2\n3\n4\nobjno 1\n")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "solved\n\nagain", "Message")
+	assert.Equal(sol.Dual, []float64{1, 2}, "Dual values")
+	assert.Equal(sol.Primal, []float64{3, 4}, "Primal values")
+	assert.Equal(sol.SolveResultNum, -1, "Solve result")
+
+	sol, err = readTempSolution(t, "primal only\n\n3\n4\n")
+	assert.Nil(err, "No error")
+	assert.Nil(sol.Dual, "No dual values")
+	assert.Equal(sol.Primal, []float64{3, 4}, "Primal values")
+	assert.Equal(sol.Objective, -1, "Objective")
+}
+
+/* Malformed solutions return an error with the line number */
+func TestReadBadSolution(t *testing.T) {
+	assert := assert.New(t)
+	_, err := readTempSolution(t, "msg\n\nOptions\n3\n1\n1\n0\n3\n3\n2\n2\n")
+	assert.Contains(err.Error(), "at line 11: solution has 3 constraints and 2 variables, expected 2 and 2", "Wrong size")
+	_, err = readTempSolution(t, "msg\n\n1\n2\n3\n")
+	assert.Contains(err.Error(), "solution has 3 values, expected 4", "Wrong number of values")
+	_, err = readTempSolution(t, "msg\n\n1\n2\nobjno 1 0\nsuffix 0 1 8 0 0\nsstatus\n5 1\n")
+	assert.Contains(err.Error(), "at line 8: bad index for suffix sstatus", "Bad index")
+	_, err = readTempSolution(t, "msg\n\n1\nx\n")
+	assert.Contains(err.Error(), "at line 4: expected a number", "Bad number")
+	_, err = readTempSolution(t, "binary\x00\x00")
+	assert.Contains(err.Error(), "not a text or binary .sol file", "Binary")
+}
+
+/* Write a solution for the nonlinear problem and read it back */
+func TestWriteSolution(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile(testNonLinearModelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "hs1.nl")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := ProblemFromFile(path)
+	defer p.Close()
+	sstatus := &Suffix{Name: "sstatus", Kind: SuffixVariable, Values: map[int]float64{0: 1, 1: 3}}
+	slack := &Suffix{Name: "slack", Kind: SuffixConstraint, Real: true, Values: map[int]float64{1: 0.25}}
+	status := &Suffix{Name: "status", Kind: SuffixProblem, Table: "0\tgood\n1\tbad", Values: map[int]float64{0: 1}}
+	p.SetSolveResultNum(100)
+	err = p.WriteSolution("Go solver: done\n\nsecond line", []float64{-0.5, 0.75}, []float64{0, 0.5}, []*Suffix{sstatus, slack, status})
+	assert.Nil(err, "No error")
+
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "Go solver: done\n\nsecond line", "Message")
+	assert.Equal(sol.Primal, []float64{-0.5, 0.75}, "Primal values")
+	assert.Equal(sol.Dual, []float64{0, 0.5}, "Dual values")
+	assert.Equal(sol.SolveResultNum, 100, "Solve result")
+	assert.Equal(sol.SolveResult(), "solved?", "Solve result")
+	assert.Equal(sol.Suffix("sstatus", SuffixVariable), sstatus, "sstatus")
+	assert.Equal(sol.Suffix("slack", SuffixConstraint), slack, "slack")
+	assert.Equal(sol.Suffix("status", SuffixProblem), status, "status")
+
+	err = p.WriteSolution("no duals", []float64{1, 2}, nil, nil)
+	assert.Nil(err, "No error")
+	sol, err = p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Nil(sol.Dual, "No dual values")
+	assert.Equal(sol.Primal, []float64{1, 2}, "Primal values")
+
+	err = p.WriteSolution("bad", []float64{1}, nil, nil)
+	assert.NotNil(err, "Wrong number of variables")
+	err = p.WriteSolution("bad", nil, nil, []*Suffix{{Name: "bad", Kind: SuffixConstraint, Values: map[int]float64{2: 1}}})
+	assert.NotNil(err, "Suffix index out of range")
+}
+
+/* Solutions for binary .nl files are written as binary .sol files, which can be read back */
+func TestWriteBinarySolution(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile("suffix_binary.nl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "suffix_binary.nl")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := ProblemFromFile(path)
+	defer p.Close()
+	sstatus := &Suffix{Name: "sstatus", Kind: SuffixVariable, Values: map[int]float64{0: 1, 1: 3}}
+	slack := &Suffix{Name: "slack", Kind: SuffixConstraint, Real: true, Values: map[int]float64{1: 0.25}}
+	status := &Suffix{Name: "status", Kind: SuffixProblem, Table: "0\tgood\n1\tbad", Values: map[int]float64{0: 1}}
+	p.SetSolveResultNum(100)
+	err = p.WriteSolution("Go solver: done\n\nsecond line", []float64{-0.5, 0.75}, []float64{0, 0.5}, []*Suffix{sstatus, slack, status})
+	assert.Nil(err, "No error")
+	written, err := ioutil.ReadFile(filepath.Join(dir, "suffix_binary.sol"))
+	assert.Nil(err, "No error")
+	assert.True(isBinarySolution(written), "Binary .sol file")
+
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "Go solver: done\n\nsecond line", "Message")
+	assert.Equal(sol.Primal, []float64{-0.5, 0.75}, "Primal values")
+	assert.Equal(sol.Dual, []float64{0, 0.5}, "Dual values")
+	assert.Equal(sol.SolveResultNum, 100, "Solve result")
+	assert.Equal(sol.Suffix("sstatus", SuffixVariable), sstatus, "sstatus")
+	assert.Equal(sol.Suffix("slack", SuffixConstraint), slack, "slack")
+	assert.Equal(sol.Suffix("status", SuffixProblem), status, "status")
+
+	assert.Nil(p.WriteSolution("", []float64{1, 2}, nil, nil), "No error")
+	sol, err = p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "", "No message")
+	assert.Nil(sol.Dual, "No dual values")
+	assert.Equal(sol.Primal, []float64{1, 2}, "Primal values")
+}
+
+/* Suffixes attached to the problem are written with the solution */
+func TestWriteAttachedSuffixes(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data, err := ioutil.ReadFile(testSuffixModelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "suffix.nl")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadProblemWithSuffixes(path, testSuffixDecls)
+	assert.Nil(err, "No error")
+	defer p.Close()
+	sstatus, err := p.Suffix("sstatus", SuffixVariable)
+	assert.Nil(err, "No error")
+	sstatus.Values[0] = 2
+	assert.Nil(p.SetSuffix(sstatus), "No error")
+	assert.Nil(p.SetSuffix(&Suffix{Name: "iis", Kind: SuffixConstraint, Values: map[int]float64{1: 1}}), "No error")
+	replaced := &Suffix{Name: "iis", Kind: SuffixConstraint, Values: map[int]float64{0: 1}}
+	assert.Nil(p.WriteSolution("done", []float64{1, 2}, nil, []*Suffix{replaced}), "No error")
+
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Suffix("sstatus", SuffixVariable).Values, map[int]float64{0: 2, 1: 3}, "sstatus")
+	assert.Equal(sol.Suffix("iis", SuffixConstraint), replaced, "iis")
+	assert.Equal(len(sol.Suffixes), 2, "Suffixes")
+}
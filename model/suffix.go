@@ -0,0 +1,238 @@
+package model
+
+/*
+#include "asl.h"
+#include <stdlib.h>
+#include <string.h>
+
+// Find a suffix declared for the problem without exiting if it's missing, unlike suf_get
+SufDesc *findSuffix(ASL *asl, const char *name, int kind) {
+	SufDesc *d;
+
+	for(d = asl->i.suffixes[kind]; d; d = d->next)
+		if (!strcmp(d->sufname, name))
+			return d;
+	return 0;
+}
+
+// The number of values a suffix of this kind has
+int suffixSize(ASL *asl, int kind) {
+	return (&asl->i.n_var_)[kind];
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+/* What a suffix is attached to. The values match the ASL_Sufkind codes */
+type SuffixKind = types.SuffixKind
+
+const (
+	SuffixVariable   = types.SuffixVariable
+	SuffixConstraint = types.SuffixConstraint
+	SuffixObjective  = types.SuffixObjective
+	SuffixProblem    = types.SuffixProblem
+)
+
+// Flags stored with the kind of a suffix in `.nl` and `.sol` files
+const (
+	suffixKindMask  = 3
+	suffixKindReal  = 4
+	suffixKindIODcl = 8
+)
+
+/* A suffix holds extra values for the variables, constraints, objectives or the problem itself,
+   like the basis status in `sstatus`. Values that aren't set are 0 */
+type Suffix struct {
+	Name string
+	Kind SuffixKind
+
+	/* Whether the values are real numbers rather than integers */
+	Real bool
+
+	/* Whether AMPL should declare the suffix as INOUT if it doesn't exist yet */
+	InOut bool
+
+	/* An optional table mapping integer values to names, one "value name [description]" entry per line */
+	Table string
+
+	/* The nonzero values, indexed by variable, constraint or objective. Problem suffixes use index 0 */
+	Values map[int]float64
+}
+
+/* Get the value of the suffix for the given index */
+func (s *Suffix) Value(index int) float64 {
+	return s.Values[index]
+}
+
+/* A suffix to read from the `.nl` file. Suffixes have to be declared before the file is read,
+   see `LoadProblemWithSuffixes` */
+type SuffixDecl struct {
+	Name string
+	Kind SuffixKind
+
+	/* Whether the values are real numbers rather than integers */
+	Real bool
+
+	/* Whether AMPL should declare the suffix as INOUT if it doesn't exist yet */
+	InOut bool
+
+	/* An optional table mapping integer values to names, returned to AMPL with the suffix */
+	Table string
+}
+
+/* Declare the suffixes to AMPL before reading the `.nl` file. Returns the C strings for the names and tables,
+   which have to be freed after the ASL structure */
+func declareSuffixes(asl *C.ASL, suffixes []SuffixDecl) []*C.char {
+	if len(suffixes) == 0 {
+		return nil
+	}
+	var strs []*C.char
+	// suf_declare copies the declarations, but keeps the strings
+	decls := make([]C.SufDecl, len(suffixes))
+	for i, suf := range suffixes {
+		decls[i].name = C.CString(suf.Name)
+		strs = append(strs, decls[i].name)
+		if suf.Table != "" {
+			decls[i].table = C.CString(suf.Table)
+			strs = append(strs, decls[i].table)
+		}
+		decls[i].kind = C.int(suf.Kind) & C.ASL_Sufkind_mask
+		if suf.Real {
+			decls[i].kind |= C.ASL_Sufkind_real
+		}
+		if suf.InOut {
+			decls[i].kind |= C.ASL_Sufkind_iodcl
+		}
+	}
+	declsC := (*C.SufDecl)(C.malloc(C.size_t(len(decls)) * C.size_t(unsafe.Sizeof(decls[0]))))
+	defer C.free(unsafe.Pointer(declsC))
+	copy((*[1 << 30]C.SufDecl)(unsafe.Pointer(declsC))[:len(decls):len(decls)], decls)
+	C.suf_declare_ASL(asl, declsC, C.int(len(decls)))
+	return strs
+}
+
+func freeCStrings(strs []*C.char) {
+	for _, s := range strs {
+		C.free(unsafe.Pointer(s))
+	}
+}
+
+/* Get the values AMPL sent for a suffix declared with `LoadProblemWithSuffixes`. The suffix has no values
+   if AMPL didn't send any. Returns an error if the suffix wasn't declared */
+func (p *Problem) Suffix(name string, kind SuffixKind) (*Suffix, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	suf, _, err := p.suffix(name, kind)
+	return suf, err
+}
+
+/* Get the values of an integer suffix, with one entry per variable, constraint or objective, or a single entry
+   for a problem suffix. The values are all 0 if AMPL didn't send any */
+func (p *Problem) IntSuffix(name string, kind SuffixKind) ([]int, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	suf, n, err := p.suffix(name, kind)
+	if err != nil {
+		return nil, err
+	}
+	if suf.Real {
+		return nil, fmt.Errorf("Error: Suffix %s was declared as real", name)
+	}
+	vals := make([]int, n)
+	for i, val := range suf.Values {
+		vals[i] = int(val)
+	}
+	return vals, nil
+}
+
+/* Get the values of a real suffix, with one entry per variable, constraint or objective, or a single entry
+   for a problem suffix. The values are all 0 if AMPL didn't send any */
+func (p *Problem) RealSuffix(name string, kind SuffixKind) ([]float64, error) {
+	if err := p.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.release()
+	suf, n, err := p.suffix(name, kind)
+	if err != nil {
+		return nil, err
+	}
+	if !suf.Real {
+		return nil, fmt.Errorf("Error: Suffix %s was declared as integer", name)
+	}
+	vals := make([]float64, n)
+	for i, val := range suf.Values {
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+/* Copy the values of a declared suffix out of the ASL structure, along with the number of entries it has */
+func (p *Problem) suffix(name string, kind SuffixKind) (*Suffix, int, error) {
+	if kind < SuffixVariable || kind > SuffixProblem {
+		return nil, 0, fmt.Errorf("Error: Unknown kind of suffix %s: %d", name, kind)
+	}
+	nameC := C.CString(name)
+	defer C.free(unsafe.Pointer(nameC))
+	d := C.findSuffix(p.asl, nameC, C.int(kind))
+	if d == nil {
+		return nil, 0, fmt.Errorf("Error: %s suffix %s was not declared", kind, name)
+	}
+	n := int(C.suffixSize(p.asl, C.int(kind)))
+	suf := &Suffix{
+		Name:   name,
+		Kind:   kind,
+		Real:   d.kind&C.ASL_Sufkind_real != 0,
+		InOut:  d.kind&C.ASL_Sufkind_iodcl != 0,
+		Table:  C.GoString(d.table),
+		Values: make(map[int]float64),
+	}
+	if d.kind&C.ASL_Sufkind_input == 0 {
+		return suf, n, nil
+	}
+	if suf.Real {
+		vals := (*[1 << 30]C.real)(unsafe.Pointer(d.u.r))[:n:n]
+		for i, val := range vals {
+			if val != 0 {
+				suf.Values[i] = float64(val)
+			}
+		}
+	} else {
+		vals := (*[1 << 30]C.int)(unsafe.Pointer(d.u.i))[:n:n]
+		for i, val := range vals {
+			if val != 0 {
+				suf.Values[i] = float64(val)
+			}
+		}
+	}
+	return suf, n, nil
+}
+
+/* Attach an output suffix to the problem, to be written to the `.sol` file by `WriteSolution`.
+   Replaces any suffix set before with the same name and kind */
+func (p *Problem) SetSuffix(suf *Suffix) error {
+	if err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release()
+	if suf.Kind < SuffixVariable || suf.Kind > SuffixProblem {
+		return fmt.Errorf("Error: Unknown kind of suffix %s: %d", suf.Name, suf.Kind)
+	}
+	for i, s := range p.outSuffixes {
+		if s.Name == suf.Name && s.Kind == suf.Kind {
+			p.outSuffixes[i] = suf
+			return nil
+		}
+	}
+	p.outSuffixes = append(p.outSuffixes, suf)
+	return nil
+}
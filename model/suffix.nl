@@ -0,0 +1,62 @@
+g3 1 1 0	# problem suffix
+ 2 2 1 0 0	# vars, constraints, objectives, ranges, eqns
+ 1 1	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 2 2 2	# nonlinear vars in constraints, objectives, both
+ 0 0 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 4 2	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+C0	#c1
+o0	#+
+o5	#^
+v0	#x[1]
+n2
+o5	#^
+v1	#x[2]
+n2
+C1	#c2
+n0
+O0 0	#f
+o54	#sumlist
+3
+o5	#^
+v0	#x[1]
+n2
+o2	#*
+v0	#x[1]
+v1	#x[2]
+o44	#exp
+v1	#x[2]
+x2	# initial guess
+0 1
+1 2
+r	#2 ranges (rhs's)
+1 4
+2 1
+b	#2 bounds (on variables)
+3
+0 -10 10
+S0 2 sstatus
+0 1
+1 3
+S5 1 scale
+1 2.5
+S2 1 priority
+0 4
+S3 1 custom
+0 7
+S1 1 unused
+0 9
+k1	#intermediate Jacobian column lengths
+2
+J0 2
+0 0
+1 0
+J1 2
+0 1
+1 2
+G0 2
+0 0
+1 0
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testSuffixModelFile = "suffix.nl"
+
+var testSuffixDecls = []SuffixDecl{
+	{Name: "sstatus", Kind: SuffixVariable},
+	{Name: "scale", Kind: SuffixConstraint, Real: true},
+	{Name: "priority", Kind: SuffixObjective},
+	{Name: "custom", Kind: SuffixProblem, Real: true, InOut: true},
+	{Name: "missing", Kind: SuffixVariable, Table: "0\tnone\n1\tsome"},
+}
+
+/* Read the values of declared suffixes from the .nl file */
+func TestReadSuffixes(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblemWithSuffixes(testSuffixModelFile, testSuffixDecls)
+	assert.Nil(err, "No error")
+	defer p.Close()
+
+	sstatus, err := p.IntSuffix("sstatus", SuffixVariable)
+	assert.Nil(err, "No error")
+	assert.Equal(sstatus, []int{1, 3}, "sstatus")
+	scale, err := p.RealSuffix("scale", SuffixConstraint)
+	assert.Nil(err, "No error")
+	assert.Equal(scale, []float64{0, 2.5}, "scale")
+	priority, err := p.IntSuffix("priority", SuffixObjective)
+	assert.Nil(err, "No error")
+	assert.Equal(priority, []int{4}, "priority")
+	custom, err := p.Suffix("custom", SuffixProblem)
+	assert.Nil(err, "No error")
+	assert.Equal(custom, &Suffix{Name: "custom", Kind: SuffixProblem, Real: true, InOut: true, Values: map[int]float64{0: 7}}, "custom")
+
+	// Declared suffixes AMPL didn't send have no values
+	missing, err := p.Suffix("missing", SuffixVariable)
+	assert.Nil(err, "No error")
+	assert.Equal(missing.Table, "0\tnone\n1\tsome", "Table")
+	assert.Equal(len(missing.Values), 0, "No values")
+	vals, err := p.IntSuffix("missing", SuffixVariable)
+	assert.Nil(err, "No error")
+	assert.Equal(vals, []int{0, 0}, "Zero values")
+
+	// Suffixes in the file that weren't declared are skipped
+	_, err = p.Suffix("unused", SuffixConstraint)
+	assert.NotNil(err, "Not declared")
+	_, err = p.RealSuffix("sstatus", SuffixVariable)
+	assert.NotNil(err, "Wrong type")
+
+	// The values are read again for clones
+	clone, err := p.Clone()
+	assert.Nil(err, "No error")
+	defer clone.Close()
+	sstatus, err = clone.IntSuffix("sstatus", SuffixVariable)
+	assert.Nil(err, "No error")
+	assert.Equal(sstatus, []int{1, 3}, "sstatus")
+}
+
+/* Suffixes aren't available without declaring them */
+func TestUndeclaredSuffixes(t *testing.T) {
+	assert := assert.New(t)
+	p := ProblemFromFile(testSuffixModelFile)
+	defer p.Close()
+	_, err := p.Suffix("sstatus", SuffixVariable)
+	assert.NotNil(err, "Not declared")
+	vals, err := p.ConstraintValues([]float64{1, 2})
+	assert.Nil(err, "No error")
+	assert.Equal(vals, []float64{5, 5}, "Constraint values")
+}
@@ -1,44 +1,17 @@
 package model
 
 import (
-	"strconv"
+	"github.com/alanctgardner/ampl-go/types"
 )
 
-type VariableType int
+/* Variables and their types are shared with the nl package, see the types package */
+type VariableType = types.VariableType
 
 const (
-	VariableReal VariableType = iota
-	VariableInteger
-	VariableBinary
-	VariableArc
+	VariableReal    = types.VariableReal
+	VariableInteger = types.VariableInteger
+	VariableBinary  = types.VariableBinary
+	VariableArc     = types.VariableArc
 )
 
-func (t VariableType) String() string {
-	switch t {
-	case VariableReal:
-		return "Real"
-	case VariableInteger:
-		return "Integer"
-	case VariableBinary:
-		return "Binary"
-	case VariableArc:
-		return "Arc"
-	}
-	return "Unknown"
-}
-
-type Variable struct {
-	Name       string
-	Type       VariableType
-	LowerBound float64
-	UpperBound float64
-	Index      int
-}
-
-func (v Variable) String() string {
-	str := "Name: " + v.Name
-	str += " Type: " + v.Type.String()
-	str += " Min: " + strconv.FormatFloat(v.LowerBound, 'E', -1, 64)
-	str += " Max: " + strconv.FormatFloat(v.UpperBound, 'E', -1, 64)
-	return str
-}
+type Variable = types.Variable
@@ -0,0 +1,58 @@
+package nl
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/alanctgardner/ampl-go/description"
+)
+
+/* Describe the structure of the problem, as `Problem.Description` does in the model package */
+func (p *Problem) Description() *description.Problem {
+	name := ""
+	if p.Name != "" {
+		name = strings.TrimSuffix(filepath.Base(p.Name), ".nl")
+	}
+	d := &description.Problem{
+		Version:     description.SchemaVersion,
+		Name:        name,
+		Variables:   make([]description.Variable, len(p.variables)),
+		Constraints: make([]description.Constraint, len(p.constraints)),
+		Objectives:  make([]description.Objective, len(p.objectives)),
+	}
+	for i, v := range p.variables {
+		d.Variables[i] = description.Variable{
+			Index:      v.Index,
+			Name:       v.Name,
+			Type:       description.DescribeVariableType(v.Type),
+			LowerBound: description.Bound(v.LowerBound),
+			UpperBound: description.Bound(v.UpperBound),
+		}
+	}
+	for i, c := range p.constraints {
+		d.Constraints[i] = description.Constraint{
+			Index:  c.Index,
+			Name:   c.Name,
+			Sense:  description.DescribeConstraintSense(c.Sense),
+			Shape:  description.DescribeShape(c.Shape),
+			Min:    description.Bound(c.Min),
+			Max:    description.Bound(c.Max),
+			Linear: description.DescribeTerms(c.LinearTerms()),
+		}
+		if p.cvar[i] >= 0 {
+			v := p.cvar[i]
+			d.Constraints[i].ComplementedVariable = &v
+		}
+	}
+	for i, o := range p.objectives {
+		d.Objectives[i] = description.Objective{
+			Index:    o.Index,
+			Name:     o.Name,
+			Sense:    description.DescribeObjectiveSense(o.Sense),
+			Shape:    description.DescribeShape(o.Shape),
+			Constant: o.Constant(),
+			Linear:   description.DescribeTerms(o.LinearTerms()),
+		}
+	}
+	return d
+}
@@ -0,0 +1,925 @@
+package nl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* Functions are evaluated by recording their expressions on a tape as they are evaluated, along with the partial
+   derivatives of every node with respect to its operands. Gradients are computed by a reverse sweep over the tape,
+   and Hessian-vector products by a forward sweep of the direction followed by a reverse sweep of the adjoints and
+   their directional derivatives. A tape is only used for a single point, so a Problem can be evaluated from
+   several goroutines at once. */
+
+/* Returned when an expression can't be evaluated at a point, e.g. taking the log of a negative number. The
+   fields match `EvalError` in the model package */
+type EvalError struct {
+	/* The operator or imported function that failed */
+	Operator string
+
+	/* The arguments passed to the operator */
+	Args []float64
+
+	/* The index of the objective being evaluated, or -1 if the error was in a constraint */
+	Objective int
+
+	/* The index of the constraint being evaluated, or -1 if the error was in an objective */
+	Constraint int
+
+	/* The order of derivative being computed: 0 for values, 1 for gradients and 2 for Hessians */
+	Derivative int
+
+	/* Why the operator can't be evaluated, if it isn't a domain error */
+	Message string
+}
+
+func (e *EvalError) Error() string {
+	str := "Error evaluating "
+	if e.Constraint >= 0 {
+		str += fmt.Sprintf("constraint %d", e.Constraint)
+	} else if e.Objective >= 0 {
+		str += fmt.Sprintf("objective %d", e.Objective)
+	} else {
+		str += "problem"
+	}
+	switch e.Derivative {
+	case 0:
+		str += ": can't evaluate "
+	case 1:
+		str += ": can't compute the gradient of "
+	default:
+		str += ": can't compute the Hessian of "
+	}
+	switch {
+	case (e.Operator == "/" || e.Operator == "div") && len(e.Args) > 0:
+		str += fmt.Sprintf("%g %s 0", e.Args[0], e.Operator)
+	case e.Message != "":
+		str += fmt.Sprintf("%s: %s", e.Operator, e.Message)
+	default:
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			args[i] = fmt.Sprintf("%g", a)
+		}
+		str += fmt.Sprintf("%s(%s)", e.Operator, strings.Join(args, ", "))
+	}
+	return str
+}
+
+/* Evaluate the constraint at point x */
+func (c Constraint) Value(x []float64) (float64, error) {
+	t, err := c.p.newTape(x, 0)
+	if err != nil {
+		return 0, err
+	}
+	root, err := t.constraint(c.Index)
+	if err != nil {
+		return 0, err
+	}
+	return t.nodes[root].value, nil
+}
+
+/* Evaluate the gradient of the constraint at point x */
+func (c Constraint) Gradient(x []float64) ([]float64, error) {
+	t, err := c.p.newTape(x, 1)
+	if err != nil {
+		return nil, err
+	}
+	root, err := t.constraint(c.Index)
+	if err != nil {
+		return nil, err
+	}
+	return t.gradient(root), nil
+}
+
+/* Evaluate the objective at point x */
+func (o Objective) Value(x []float64) (float64, error) {
+	t, err := o.p.newTape(x, 0)
+	if err != nil {
+		return 0, err
+	}
+	root, err := t.objective(o.Index)
+	if err != nil {
+		return 0, err
+	}
+	return t.nodes[root].value, nil
+}
+
+/* Evaluate the gradient of the objective at point x */
+func (o Objective) Gradient(x []float64) ([]float64, error) {
+	t, err := o.p.newTape(x, 1)
+	if err != nil {
+		return nil, err
+	}
+	root, err := t.objective(o.Index)
+	if err != nil {
+		return nil, err
+	}
+	return t.gradient(root), nil
+}
+
+/* Evaluate the value of all constraints at point x. Defined variables are only evaluated once */
+func (p *Problem) ConstraintValues(x []float64) ([]float64, error) {
+	t, err := p.newTape(x, 0)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]float64, len(p.constraints))
+	for i := range p.constraints {
+		root, err := t.constraint(i)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = t.nodes[root].value
+	}
+	return vals, nil
+}
+
+/* Evaluate the dense Jacobian of the constraints at point x. The result has one row per constraint, with the
+   derivative of constraint i with respect to variable j at index i*n+j */
+func (p *Problem) ConstraintJacobian(x []float64) ([]float64, error) {
+	t, err := p.newTape(x, 1)
+	if err != nil {
+		return nil, err
+	}
+	numVariables := len(p.variables)
+	jac := make([]float64, len(p.constraints)*numVariables)
+	for i := range p.constraints {
+		root, err := t.constraint(i)
+		if err != nil {
+			return nil, err
+		}
+		copy(jac[i*numVariables:], t.gradient(root))
+	}
+	return jac, nil
+}
+
+/* Compute the product of the Hessian of the Lagrangian
+       sum(objWeights[i] * Hessian(objective i)) + sum(multipliers[j] * Hessian(constraint j))
+   at point x with `direction`, without forming the Hessian. If `objective` is a valid index only that objective
+   is included, weighted by `objWeights[objective]` (or 1). If `objective` is -1 every objective is included,
+   weighted by `objWeights`, unless `objWeights` is nil. Any other index is an error. The constraints are included, weighted by `multipliers`,
+   unless `multipliers` is nil. This matches `HessianVectorProduct` in the model package */
+func (p *Problem) HessianVectorProduct(x []float64, direction []float64, objective int, objWeights []float64, multipliers []float64) ([]float64, error) {
+	if len(direction) != len(p.variables) {
+		return nil, fmt.Errorf("Error: Incorrect size of direction: expected %d, got %d", len(p.variables), len(direction))
+	}
+	t, root, err := p.lagrangian(x, objective, objWeights, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	return t.hessianVector(root, direction), nil
+}
+
+/* Compute the dense Hessian of the Lagrangian at point x. The objective weights and multipliers are used as in
+   `HessianVectorProduct`. The result has one row per variable, with entry (i, j) at index i*n+j */
+func (p *Problem) FullHessian(x []float64, objective int, objWeights []float64, multipliers []float64) ([]float64, error) {
+	t, root, err := p.lagrangian(x, objective, objWeights, multipliers)
+	if err != nil {
+		return nil, err
+	}
+	numVariables := len(p.variables)
+	hes := make([]float64, numVariables*numVariables)
+	direction := make([]float64, numVariables)
+	for j, pos := range t.vars {
+		/* Variables that aren't used have a column of zeros */
+		if pos < 0 {
+			continue
+		}
+		direction[j] = 1
+		column := t.hessianVector(root, direction)
+		direction[j] = 0
+		for i, h := range column {
+			hes[i*numVariables+j] = h
+		}
+	}
+	return hes, nil
+}
+
+/* Record the Lagrangian on a tape, returning the tape and the position of the Lagrangian */
+func (p *Problem) lagrangian(x []float64, objective int, objWeights []float64, multipliers []float64) (*tape, int, error) {
+	if objWeights != nil && len(objWeights) != len(p.objectives) {
+		return nil, 0, fmt.Errorf("Error: Incorrect number of objective weights: expected %d, got %d", len(p.objectives), len(objWeights))
+	}
+	if multipliers != nil && len(multipliers) != len(p.constraints) {
+		return nil, 0, fmt.Errorf("Error: Incorrect number of multipliers: expected %d, got %d", len(p.constraints), len(multipliers))
+	}
+	if objective < -1 || objective >= len(p.objectives) {
+		return nil, 0, fmt.Errorf("Error: Objective index %d out of range", objective)
+	}
+	t, err := p.newTape(x, 2)
+	if err != nil {
+		return nil, 0, err
+	}
+	var args []int
+	var weights []float64
+	for i := range p.objectives {
+		weight := 1.0
+		if objWeights != nil {
+			weight = objWeights[i]
+		} else if objective < 0 {
+			break
+		}
+		if objective >= 0 && i != objective {
+			continue
+		}
+		pos, err := t.objective(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		args = append(args, pos)
+		weights = append(weights, weight)
+	}
+	for i := range multipliers {
+		pos, err := t.constraint(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		args = append(args, pos)
+		weights = append(weights, multipliers[i])
+	}
+	value := 0.0
+	for i, pos := range args {
+		value += weights[i] * t.nodes[pos].value
+	}
+	return t, t.push(value, args, weights), nil
+}
+
+/* A node on a tape: its value and its partial derivatives with respect to its operands */
+type tapeNode struct {
+	args  []int
+	value float64
+	d     []float64
+
+	/* The second partial derivatives of a node with one or two operands: with respect to the first operand
+	   twice, to both operands, and to the second operand twice. Only used if `curved` is set */
+	d2     [3]float64
+	curved bool
+
+	/* The index of the variable a leaf holds, or -1 */
+	variable int
+}
+
+type tape struct {
+	p     *Problem
+	x     []float64
+	nodes []tapeNode
+
+	/* The order of derivatives being computed, which decides which derivative errors are reported */
+	order int
+
+	/* The position of each variable and defined variable on the tape, or -1 before it's recorded */
+	vars    []int
+	defined []int
+
+	/* The objective or constraint being recorded, for errors */
+	obj, con int
+}
+
+func (p *Problem) newTape(x []float64, order int) (*tape, error) {
+	if len(x) != len(p.variables) {
+		return nil, fmt.Errorf("Error: Incorrect number of variables in input: expected %d, got %d", len(p.variables), len(x))
+	}
+	t := &tape{p: p, x: x, order: order, obj: -1, con: -1}
+	t.vars = make([]int, len(p.variables))
+	for i := range t.vars {
+		t.vars[i] = -1
+	}
+	t.defined = make([]int, len(p.defined))
+	for i := range t.defined {
+		t.defined[i] = -1
+	}
+	return t, nil
+}
+
+/* Add a node to the tape, returning its position */
+func (t *tape) push(value float64, args []int, d []float64) int {
+	t.nodes = append(t.nodes, tapeNode{args: args, value: value, d: d, variable: -1})
+	return len(t.nodes) - 1
+}
+
+/* Add a node with one or two operands and second derivatives */
+func (t *tape) pushCurved(value float64, args []int, d []float64, d2 [3]float64) int {
+	pos := t.push(value, args, d)
+	t.nodes[pos].d2 = d2
+	t.nodes[pos].curved = true
+	return pos
+}
+
+/* Add a node whose derivatives are 0, like a comparison */
+func (t *tape) constant(value float64) int {
+	return t.push(value, nil, nil)
+}
+
+func (t *tape) variable(i int) int {
+	if t.vars[i] < 0 {
+		t.vars[i] = t.push(t.x[i], nil, nil)
+		t.nodes[t.vars[i]].variable = i
+	}
+	return t.vars[i]
+}
+
+/* Record an objective, returning its position */
+func (t *tape) objective(i int) (int, error) {
+	t.obj, t.con = i, -1
+	return t.function(t.p.objLinear[i], t.p.objExprs[i])
+}
+
+/* Record a constraint, returning its position */
+func (t *tape) constraint(i int) (int, error) {
+	t.obj, t.con = -1, i
+	return t.function(t.p.conLinear[i], t.p.conExprs[i])
+}
+
+/* Record the sum of linear terms and an expression */
+func (t *tape) function(linear []Term, e *Expr) (int, error) {
+	args := make([]int, 0, len(linear)+1)
+	d := make([]float64, 0, len(linear)+1)
+	value := 0.0
+	for _, term := range linear {
+		pos, err := t.reference(term.Index)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, pos)
+		d = append(d, term.Coefficient)
+		value += term.Coefficient * t.nodes[pos].value
+	}
+	pos, err := t.record(e)
+	if err != nil {
+		return 0, err
+	}
+	args = append(args, pos)
+	d = append(d, 1)
+	value += t.nodes[pos].value
+	return t.push(value, args, d), nil
+}
+
+/* Record a variable or defined variable by its index in expressions */
+func (t *tape) reference(index int) (int, error) {
+	numVariables := len(t.p.variables)
+	if index < numVariables {
+		return t.variable(index), nil
+	}
+	i := index - numVariables
+	if t.defined[i] < 0 {
+		pos, err := t.function(t.p.defined[i].Linear, t.p.defined[i].Expr)
+		if err != nil {
+			return 0, err
+		}
+		t.defined[i] = pos
+	}
+	return t.defined[i], nil
+}
+
+/* Build an error for an operator that can't be evaluated */
+func (t *tape) fail(op string, derivative int, args ...float64) error {
+	return &EvalError{Operator: op, Args: args, Objective: t.obj, Constraint: t.con, Derivative: derivative}
+}
+
+/* Whether a derivative error of the given order should be reported */
+func (t *tape) checks(derivative int) bool {
+	return t.order >= derivative
+}
+
+/* Record the operands of an expression, returning their positions and values */
+func (t *tape) operands(args []*Expr) ([]int, []float64, error) {
+	pos := make([]int, len(args))
+	vals := make([]float64, len(args))
+	for i, arg := range args {
+		var err error
+		if pos[i], err = t.record(arg); err != nil {
+			return nil, nil, err
+		}
+		vals[i] = t.nodes[pos[i]].value
+	}
+	return pos, vals, nil
+}
+
+/* Evaluate an expression, recording it on the tape. Returns the position of its value */
+func (t *tape) record(e *Expr) (int, error) {
+	switch e.Op {
+	case OpNumber:
+		return t.constant(e.Value), nil
+	case OpVariable:
+		return t.reference(e.Index)
+	case OpIf, OpImpElse:
+		cond, err := t.record(e.Args[0])
+		if err != nil {
+			return 0, err
+		}
+		branch := e.Args[2]
+		if t.nodes[cond].value != 0 {
+			branch = e.Args[1]
+		}
+		pos, err := t.record(branch)
+		if err != nil {
+			return 0, err
+		}
+		return t.push(t.nodes[pos].value, []int{pos}, []float64{1}), nil
+	case OpOr, OpOrList:
+		for _, arg := range e.Args {
+			pos, err := t.record(arg)
+			if err != nil {
+				return 0, err
+			}
+			if t.nodes[pos].value != 0 {
+				return t.constant(1), nil
+			}
+		}
+		return t.constant(0), nil
+	case OpAnd, OpAndList:
+		for _, arg := range e.Args {
+			pos, err := t.record(arg)
+			if err != nil {
+				return 0, err
+			}
+			if t.nodes[pos].value == 0 {
+				return t.constant(0), nil
+			}
+		}
+		return t.constant(1), nil
+	case OpFuncall:
+		name := ""
+		if e.Index < len(t.p.functions) {
+			name = t.p.functions[e.Index].Name
+		}
+		return 0, &EvalError{Operator: name, Objective: t.obj, Constraint: t.con, Derivative: t.order,
+			Message: "imported functions can only be evaluated by AMPL's solver library"}
+	case OpString, OpIfSym, OpNumberOfSym:
+		return t.symbolic(e)
+	}
+
+	args, vals, err := t.operands(e.Args)
+	if err != nil {
+		return 0, err
+	}
+	switch len(e.Args) {
+	case 1:
+		if pos, ok, err := t.unary(e.Op, args[0], vals[0]); ok || err != nil {
+			return pos, err
+		}
+	case 2:
+		if pos, ok, err := t.binary(e, args, vals[0], vals[1]); ok || err != nil {
+			return pos, err
+		}
+	}
+	switch e.Op {
+	case OpSumList:
+		value := 0.0
+		d := make([]float64, len(args))
+		for i, v := range vals {
+			value += v
+			d[i] = 1
+		}
+		return t.push(value, args, d), nil
+	case OpMinList, OpMaxList:
+		/* Only the first operand with the smallest or largest value contributes to the derivatives */
+		chosen := 0
+		for i, v := range vals {
+			if (e.Op == OpMinList && v < vals[chosen]) || (e.Op == OpMaxList && v > vals[chosen]) {
+				chosen = i
+			}
+		}
+		return t.push(vals[chosen], []int{args[chosen]}, []float64{1}), nil
+	case OpCount:
+		count := 0.0
+		for _, v := range vals {
+			if v != 0 {
+				count++
+			}
+		}
+		return t.constant(count), nil
+	case OpNumberOf:
+		count := 0.0
+		for _, v := range vals[1:] {
+			if v == vals[0] {
+				count++
+			}
+		}
+		return t.constant(count), nil
+	case OpAllDiff:
+		sorted := append([]float64(nil), vals...)
+		sort.Float64s(sorted)
+		for i := 1; i < len(sorted); i++ {
+			if sorted[i] == sorted[i-1] {
+				return t.constant(0), nil
+			}
+		}
+		return t.constant(1), nil
+	case OpPLTerm:
+		value, slope := piecewiseLinear(e.Slopes, e.Breakpoints, vals[0])
+		return t.push(value, args, []float64{slope}), nil
+	}
+	return 0, &EvalError{Operator: e.Op.String(), Args: vals, Objective: t.obj, Constraint: t.con, Derivative: t.order,
+		Message: "unknown operator"}
+}
+
+/* Record an operator with one operand. Returns false if the operator has a different number of operands */
+func (t *tape) unary(op Opcode, arg int, x float64) (int, bool, error) {
+	var value, d, d2 float64
+	name := op.String()
+	switch op {
+	case OpUMinus:
+		return t.push(-x, []int{arg}, []float64{-1}), true, nil
+	case OpAbs:
+		if x < 0 {
+			return t.push(-x, []int{arg}, []float64{-1}), true, nil
+		}
+		return t.push(x, []int{arg}, []float64{1}), true, nil
+	case OpFloor:
+		return t.constant(math.Floor(x)), true, nil
+	case OpCeil:
+		return t.constant(math.Ceil(x)), true, nil
+	case OpNot:
+		if x == 0 {
+			return t.constant(1), true, nil
+		}
+		return t.constant(0), true, nil
+	case OpTanh:
+		value = math.Tanh(x)
+		c := math.Cosh(x)
+		if math.IsInf(c, 0) && t.checks(1) {
+			return 0, true, t.fail("tanh", 1, x)
+		}
+		d = 1 / (c * c)
+		d2 = -2 * value * d
+	case OpTan:
+		value = math.Tan(x)
+		c := math.Cos(x)
+		if c == 0 && t.checks(1) {
+			return 0, true, t.fail("tan", 1, x)
+		}
+		d = 1 / (c * c)
+		d2 = 2 * value * d
+	case OpSqrt:
+		if x < 0 {
+			return 0, true, t.fail("sqrt", 0, x)
+		}
+		value = math.Sqrt(x)
+		if value <= 0 && t.checks(1) {
+			return 0, true, t.fail("sqrt", 1, x)
+		}
+		d = 0.5 / value
+		d2 = -0.5 * d / x
+	case OpSinh:
+		value = math.Sinh(x)
+		d = math.Cosh(x)
+		if math.IsInf(d, 0) && t.checks(1) {
+			return 0, true, t.fail("sinh", 1, x)
+		}
+		d2 = value
+	case OpSin:
+		value = math.Sin(x)
+		d = math.Cos(x)
+		d2 = -value
+	case OpLog10:
+		if x <= 0 {
+			return 0, true, t.fail("log10", 0, x)
+		}
+		value = math.Log10(x)
+		d = 1 / (x * math.Ln10)
+		d2 = -d / x
+	case OpLog:
+		if x <= 0 {
+			return 0, true, t.fail("log", 0, x)
+		}
+		value = math.Log(x)
+		d = 1 / x
+		d2 = -d * d
+	case OpExp:
+		/* Underflow to 0 isn't an error */
+		value = math.Exp(x)
+		d, d2 = value, value
+	case OpCosh:
+		value = math.Cosh(x)
+		d = math.Sinh(x)
+		if math.IsInf(d, 0) && t.checks(1) {
+			return 0, true, t.fail("cosh", 1, x)
+		}
+		d2 = value
+	case OpCos:
+		value = math.Cos(x)
+		d = -math.Sin(x)
+		d2 = -value
+	case OpAtanh:
+		if x <= -1 || x >= 1 {
+			return 0, true, t.fail("atanh", 0, x)
+		}
+		value = math.Atanh(x)
+		d = 1 / (1 - x*x)
+		d2 = 2 * x * d * d
+	case OpAtan:
+		value = math.Atan(x)
+		d = 1 / (1 + x*x)
+		d2 = -2 * x * d * d
+	case OpAsinh:
+		value = math.Asinh(x)
+		d = 1 / math.Sqrt(x*x+1)
+		d2 = -x / (x*x + 1) * d
+	case OpAsin, OpAcos:
+		value = math.Asin(x)
+		if op == OpAcos {
+			value = math.Acos(x)
+		}
+		if math.IsNaN(value) {
+			return 0, true, t.fail(name, 0, x)
+		}
+		s := 1 - x*x
+		if s <= 0 && t.checks(1) {
+			return 0, true, t.fail(name, 1, x)
+		}
+		d = 1 / math.Sqrt(s)
+		if op == OpAcos {
+			d = -d
+		}
+		d2 = x * d / s
+	case OpAcosh:
+		if x < 1 {
+			return 0, true, t.fail("acosh", 0, x)
+		}
+		value = math.Acosh(x)
+		s := x*x - 1
+		if s <= 0 && t.checks(1) {
+			return 0, true, t.fail("acosh", 1, x)
+		}
+		d = 1 / math.Sqrt(s)
+		d2 = -x * d / s
+	default:
+		return 0, false, nil
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, true, t.fail(name, 0, x)
+	}
+	return t.pushCurved(value, []int{arg}, []float64{d}, [3]float64{d2}), true, nil
+}
+
+/* Record an operator with two operands. Returns false if the operator has a different number of operands */
+func (t *tape) binary(e *Expr, args []int, l, r float64) (int, bool, error) {
+	switch e.Op {
+	case OpPlus:
+		return t.push(l+r, args, []float64{1, 1}), true, nil
+	case OpMinus:
+		return t.push(l-r, args, []float64{1, -1}), true, nil
+	case OpMult:
+		return t.pushCurved(l*r, args, []float64{r, l}, [3]float64{0, 1, 0}), true, nil
+	case OpDiv:
+		if r == 0 {
+			return 0, true, t.fail("/", 0, l, 0)
+		}
+		value := l / r
+		d := 1 / r
+		return t.pushCurved(value, args, []float64{d, -value * d}, [3]float64{0, -d * d, 2 * value * d * d}), true, nil
+	case OpRem:
+		value := math.Mod(l, r)
+		if math.IsNaN(value) {
+			return 0, true, t.fail("fmod", 0, l, r)
+		}
+		return t.push(value, args, []float64{1, (value - l) / r}), true, nil
+	case OpPow:
+		return t.pow(e, args, l, r)
+	case OpLess:
+		if l < r {
+			return t.push(0, args, []float64{0, 0}), true, nil
+		}
+		return t.push(l-r, args, []float64{1, -1}), true, nil
+	case OpAtan2:
+		value := math.Atan2(l, r)
+		s := 1 / (l*l + r*r)
+		d2 := s * s * 2 * l * r
+		return t.pushCurved(value, args, []float64{s * r, -s * l}, [3]float64{-d2, s * s * (l*l - r*r), d2}), true, nil
+	case OpIntDiv:
+		if r == 0 {
+			return 0, true, t.fail("div", 0, l, 0)
+		}
+		return t.constant(math.Trunc(l / r)), true, nil
+	case OpPrecision:
+		value, _ := strconv.ParseFloat(strconv.FormatFloat(l, 'g', int(r), 64), 64)
+		return t.constant(value), true, nil
+	case OpRound:
+		return t.constant(round(l, int(r))), true, nil
+	case OpTrunc:
+		if r == 0 {
+			return t.constant(math.Trunc(l)), true, nil
+		}
+		scale := math.Pow(10, r)
+		return t.constant(math.Trunc(l*scale) / scale), true, nil
+	}
+	var holds bool
+	switch e.Op {
+	case OpLT, OpNotAtMost:
+		holds = l < r
+	case OpLE, OpAtLeast:
+		holds = l <= r
+	case OpEQ, OpExactly:
+		holds = l == r
+	case OpGE, OpAtMost:
+		holds = l >= r
+	case OpGT, OpNotAtLeast:
+		holds = l > r
+	case OpNE, OpNotExactly:
+		holds = l != r
+	case OpIff:
+		holds = (l != 0) == (r != 0)
+	default:
+		return 0, false, nil
+	}
+	if holds {
+		return t.constant(1), true, nil
+	}
+	return t.constant(0), true, nil
+}
+
+/* Record a power, with the special cases ASL has for constant bases and exponents */
+func (t *tape) pow(e *Expr, args []int, l, r float64) (int, bool, error) {
+	value := math.Pow(l, r)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, true, t.fail("pow", 0, l, r)
+	}
+	base, exponent := e.Args[0], e.Args[1]
+	switch {
+	case exponent.Op == OpNumber:
+		var d, d2 float64
+		switch {
+		case r == 2:
+			d, d2 = 2*l, 2
+		case l != 0:
+			d = r * value / l
+			d2 = (r - 1) * d / l
+		case r == 1:
+			d = 1
+		case r > 1:
+			if r < 2 && t.checks(2) {
+				return 0, true, t.fail("pow", 2, l, r)
+			}
+		case t.checks(1):
+			return 0, true, t.fail("pow", 1, l, r)
+		}
+		return t.pushCurved(value, args, []float64{d, 0}, [3]float64{d2, 0, 0}), true, nil
+	case base.Op == OpNumber:
+		var d, d2 float64
+		if l > 0 {
+			d = math.Log(l) * value
+			d2 = math.Log(l) * d
+		} else if l < 0 && t.checks(1) {
+			return 0, true, t.fail("pow", 1, l, r)
+		}
+		return t.pushCurved(value, args, []float64{0, d}, [3]float64{0, 0, d2}), true, nil
+	}
+	var dl, dr, dll, dlr, drr float64
+	switch {
+	case l > 0:
+		logl := math.Log(l)
+		dl = r * value / l
+		dr = logl * value
+		dll = (r - 1) * dl / l
+		dlr = value / l * (1 + r*logl)
+		drr = logl * dr
+	case l < 0 || (r < 1 && r != 0):
+		if t.checks(1) {
+			return 0, true, t.fail("pow", 1, l, r)
+		}
+	case r == 1:
+		dl = 1
+	case r > 1 && r < 2:
+		if t.checks(2) {
+			return 0, true, t.fail("pow", 2, l, r)
+		}
+	}
+	return t.pushCurved(value, args, []float64{dl, dr}, [3]float64{dll, dlr, drr}), true, nil
+}
+
+/* Record an operator on strings. Strings can only be counted with numberof, or passed to imported functions */
+func (t *tape) symbolic(e *Expr) (int, error) {
+	if e.Op == OpNumberOfSym {
+		strs := make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			if arg.Op != OpString {
+				return 0, &EvalError{Operator: e.Op.String(), Objective: t.obj, Constraint: t.con, Derivative: t.order,
+					Message: "only constant strings can be counted"}
+			}
+			strs[i] = arg.String
+		}
+		count := 0.0
+		for _, s := range strs[1:] {
+			if s == strs[0] {
+				count++
+			}
+		}
+		return t.constant(count), nil
+	}
+	return 0, &EvalError{Operator: e.Op.String(), Objective: t.obj, Constraint: t.con, Derivative: t.order,
+		Message: "strings can only be passed to imported functions"}
+}
+
+/* Evaluate a piecewise-linear term, which is 0 at 0. At a breakpoint the derivative is the slope of the segment
+   nearer to 0, as in ASL */
+func piecewiseLinear(slopes, breakpoints []float64, x float64) (float64, float64) {
+	value := 0.0
+	slope := math.NaN()
+	for i, s := range slopes {
+		lo, hi := math.Inf(-1), math.Inf(1)
+		if i > 0 {
+			lo = breakpoints[i-1]
+		}
+		if i < len(breakpoints) {
+			hi = breakpoints[i]
+		}
+		if x >= 0 {
+			value += s * math.Max(0, math.Min(hi, x)-math.Max(lo, 0))
+			if hi > 0 && x <= hi && math.IsNaN(slope) {
+				slope = s
+			}
+		} else {
+			value -= s * math.Max(0, math.Min(hi, 0)-math.Max(lo, x))
+			if lo < 0 && x >= lo {
+				slope = s
+			}
+		}
+	}
+	return value, slope
+}
+
+/* Round x to the given number of decimal places, which may be negative */
+func round(x float64, places int) float64 {
+	if places < 0 {
+		scale := math.Pow(10, float64(-places))
+		return math.Round(x/scale) * scale
+	}
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'f', places, 64), 64)
+	return value
+}
+
+/* Compute the gradient of the node at `root` with respect to the variables by a reverse sweep */
+func (t *tape) gradient(root int) []float64 {
+	adjoint := make([]float64, root+1)
+	adjoint[root] = 1
+	for i := root; i >= 0; i-- {
+		if adjoint[i] == 0 {
+			continue
+		}
+		n := &t.nodes[i]
+		for k, arg := range n.args {
+			adjoint[arg] += adjoint[i] * n.d[k]
+		}
+	}
+	grad := make([]float64, len(t.vars))
+	for j, pos := range t.vars {
+		if pos >= 0 && pos <= root {
+			grad[j] = adjoint[pos]
+		}
+	}
+	return grad
+}
+
+/* Compute the product of the Hessian of the node at `root` with `direction`. The forward sweep computes the
+   derivative of every node in the direction, and the reverse sweep the adjoints along with their derivatives
+   in the direction, which for the variables are the product */
+func (t *tape) hessianVector(root int, direction []float64) []float64 {
+	tangent := make([]float64, root+1)
+	for i := 0; i <= root; i++ {
+		n := &t.nodes[i]
+		if n.variable >= 0 {
+			tangent[i] = direction[n.variable]
+			continue
+		}
+		for k, arg := range n.args {
+			if tangent[arg] != 0 {
+				tangent[i] += n.d[k] * tangent[arg]
+			}
+		}
+	}
+	adjoint := make([]float64, root+1)
+	adjointTangent := make([]float64, root+1)
+	adjoint[root] = 1
+	for i := root; i >= 0; i-- {
+		if adjoint[i] == 0 && adjointTangent[i] == 0 {
+			continue
+		}
+		n := &t.nodes[i]
+		for k, arg := range n.args {
+			adjoint[arg] += adjoint[i] * n.d[k]
+			adjointTangent[arg] += adjointTangent[i] * n.d[k]
+		}
+		if !n.curved || adjoint[i] == 0 {
+			continue
+		}
+		switch len(n.args) {
+		case 1:
+			adjointTangent[n.args[0]] += adjoint[i] * n.d2[0] * tangent[n.args[0]]
+		case 2:
+			l, r := tangent[n.args[0]], tangent[n.args[1]]
+			adjointTangent[n.args[0]] += adjoint[i] * (n.d2[0]*l + n.d2[1]*r)
+			adjointTangent[n.args[1]] += adjoint[i] * (n.d2[1]*l + n.d2[2]*r)
+		}
+	}
+	hv := make([]float64, len(t.vars))
+	for j, pos := range t.vars {
+		if pos >= 0 && pos <= root {
+			hv[j] = adjointTangent[pos]
+		}
+	}
+	return hv
+}
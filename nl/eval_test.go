@@ -0,0 +1,128 @@
+package nl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+/* A problem using most of AMPL's operators, which the model package checks against AMPL's solver library */
+const testOperatorsFile = "../model/operators.nl"
+
+/* Evaluate the constraints and objective of a nonlinear problem */
+func TestEvaluate(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testNonLinearFile)
+	assert.Nil(err, "No error")
+	x := []float64{1, 2}
+	val, err := p.Objectives()[0].Value(x)
+	assert.Nil(err, "No error")
+	grad, err := p.Objectives()[0].Gradient(x)
+	assert.Nil(err, "No error")
+	for i, c := range p.Constraints() {
+		val, err := c.Value(x)
+		assert.Nil(err, "No error")
+		vals, err := p.ConstraintValues(x)
+		assert.Nil(err, "No error")
+		assert.Equal(vals[i], val, "Constraint value")
+	}
+	assert.NotEqual(val, 0.0, "Objective value")
+	assert.Equal(len(grad), 2, "Gradient size")
+
+	_, err = p.Objectives()[0].Value([]float64{1})
+	assert.Equal(err.Error(), "Error: Incorrect number of variables in input: expected 2, got 1", "Wrong number of variables")
+}
+
+/* Check the gradients and Hessians against central differences of the values and gradients */
+func TestDerivatives(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testOperatorsFile)
+	assert.Nil(err, "No error")
+	x := []float64{1.5, 1.75, 2.2}
+	const h = 1e-6
+	shift := func(j int, step float64) []float64 {
+		y := append([]float64(nil), x...)
+		y[j] += step
+		return y
+	}
+	for _, c := range p.Constraints() {
+		grad, err := c.Gradient(x)
+		assert.Nil(err, "No error")
+		for j := range x {
+			up, err := c.Value(shift(j, h))
+			assert.Nil(err, "No error")
+			down, err := c.Value(shift(j, -h))
+			assert.Nil(err, "No error")
+			assert.InDelta(grad[j], (up-down)/(2*h), 1e-6, c.Name)
+		}
+		multipliers := make([]float64, len(p.Constraints()))
+		multipliers[c.Index] = 1
+		hes, err := p.FullHessian(x, -1, nil, multipliers)
+		assert.Nil(err, "No error")
+		for j := range x {
+			up, err := c.Gradient(shift(j, h))
+			assert.Nil(err, "No error")
+			down, err := c.Gradient(shift(j, -h))
+			assert.Nil(err, "No error")
+			for i := range x {
+				assert.InDelta(hes[i*len(x)+j], (up[i]-down[i])/(2*h), 1e-5, c.Name)
+			}
+		}
+	}
+	hv, err := p.HessianVectorProduct(x, []float64{1, 0, 0}, 0, nil, nil)
+	assert.Nil(err, "No error")
+	hes, err := p.FullHessian(x, 0, nil, nil)
+	assert.Nil(err, "No error")
+	assert.Equal(hv, []float64{hes[0], hes[3], hes[6]}, "Hessian-vector product")
+
+	_, err = p.HessianVectorProduct(x, []float64{1, 0, 0}, 1, nil, nil)
+	assert.EqualError(err, "Error: Objective index 1 out of range", "Objective past the end")
+	_, err = p.FullHessian(x, -2, nil, nil)
+	assert.EqualError(err, "Error: Objective index -2 out of range", "Negative objective")
+}
+
+/* Piecewise-linear terms are 0 at 0, and use the slope nearer to 0 at a breakpoint */
+func TestPiecewiseLinear(t *testing.T) {
+	assert := assert.New(t)
+	slopes, breakpoints := []float64{-1, 1, 3}, []float64{-1, 2}
+	for _, test := range []struct{ x, value, slope float64 }{
+		{0, 0, 1},
+		{1, 1, 1},
+		{2, 2, 1},
+		{3, 5, 3},
+		{-1, -1, 1},
+		{-2, 0, -1},
+	} {
+		value, slope := piecewiseLinear(slopes, breakpoints, test.x)
+		assert.Equal(value, test.value, "Value")
+		assert.Equal(slope, test.slope, "Slope")
+	}
+}
+
+/* Errors name the operator and the objective or constraint that couldn't be evaluated */
+func TestEvalErrors(t *testing.T) {
+	assert := assert.New(t)
+	body := "F0 1 -1 myfunc\nC0\no3\nn1\nv0\nr\n1 4\nb\n3\nk0\nJ0 1\n0 0\n"
+	p, err := Read(strings.NewReader(testFunctionHeader + body))
+	assert.Nil(err, "No error")
+	_, err = p.Constraints()[0].Value([]float64{0})
+	assert.Equal(err, &EvalError{Operator: "/", Args: []float64{1, 0}, Objective: -1, Constraint: 0}, "Division by 0")
+	assert.Equal(err.Error(), "Error evaluating constraint 0: can't evaluate 1 / 0", "Error message")
+
+	p, err = LoadProblem("../model/domain.nl")
+	assert.Nil(err, "No error")
+	_, err = p.Objectives()[0].Gradient([]float64{0})
+	assert.Equal(err, &EvalError{Operator: "sqrt", Args: []float64{0}, Objective: 0, Constraint: -1, Derivative: 1}, "Gradient error")
+	val, err := p.Objectives()[0].Value([]float64{0})
+	assert.Nil(err, "Value is defined")
+	assert.Equal(val, 0.0, "Objective value")
+
+	body = "F0 1 -1 myfunc\nC0\nf0 1\nv0\nr\n1 4\nb\n3\nk0\nJ0 1\n0 0\n"
+	p, err = Read(strings.NewReader(testFunctionHeader + body))
+	assert.Nil(err, "No error")
+	_, err = p.Constraints()[0].Value([]float64{0})
+	assert.Equal(err.Error(), "Error evaluating constraint 0: can't evaluate myfunc: imported functions can only be evaluated by AMPL's solver library", "Imported function")
+
+	err = &EvalError{Operator: "div", Objective: -1, Constraint: 2}
+	assert.Equal(err.Error(), "Error evaluating constraint 2: can't evaluate div()", "No arguments")
+}
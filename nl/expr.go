@@ -0,0 +1,302 @@
+package nl
+
+/* An operator in an expression. The values match AMPL's opcodes in `r_op.hd` */
+type Opcode int
+
+const (
+	OpPlus        Opcode = 0
+	OpMinus       Opcode = 1
+	OpMult        Opcode = 2
+	OpDiv         Opcode = 3
+	OpRem         Opcode = 4
+	OpPow         Opcode = 5
+	OpLess        Opcode = 6
+	OpMinList     Opcode = 11
+	OpMaxList     Opcode = 12
+	OpFloor       Opcode = 13
+	OpCeil        Opcode = 14
+	OpAbs         Opcode = 15
+	OpUMinus      Opcode = 16
+	OpOr          Opcode = 20
+	OpAnd         Opcode = 21
+	OpLT          Opcode = 22
+	OpLE          Opcode = 23
+	OpEQ          Opcode = 24
+	OpGE          Opcode = 28
+	OpGT          Opcode = 29
+	OpNE          Opcode = 30
+	OpNot         Opcode = 34
+	OpIf          Opcode = 35
+	OpTanh        Opcode = 37
+	OpTan         Opcode = 38
+	OpSqrt        Opcode = 39
+	OpSinh        Opcode = 40
+	OpSin         Opcode = 41
+	OpLog10       Opcode = 42
+	OpLog         Opcode = 43
+	OpExp         Opcode = 44
+	OpCosh        Opcode = 45
+	OpCos         Opcode = 46
+	OpAtanh       Opcode = 47
+	OpAtan2       Opcode = 48
+	OpAtan        Opcode = 49
+	OpAsinh       Opcode = 50
+	OpAsin        Opcode = 51
+	OpAcosh       Opcode = 52
+	OpAcos        Opcode = 53
+	OpSumList     Opcode = 54
+	OpIntDiv      Opcode = 55
+	OpPrecision   Opcode = 56
+	OpRound       Opcode = 57
+	OpTrunc       Opcode = 58
+	OpCount       Opcode = 59
+	OpNumberOf    Opcode = 60
+	OpNumberOfSym Opcode = 61
+	OpAtLeast     Opcode = 62
+	OpAtMost      Opcode = 63
+	OpPLTerm      Opcode = 64
+	OpIfSym       Opcode = 65
+	OpExactly     Opcode = 66
+	OpNotAtLeast  Opcode = 67
+	OpNotAtMost   Opcode = 68
+	OpNotExactly  Opcode = 69
+	OpAndList     Opcode = 70
+	OpOrList      Opcode = 71
+	OpImpElse     Opcode = 72
+	OpIff         Opcode = 73
+	OpAllDiff     Opcode = 74
+	OpFuncall     Opcode = 78
+	OpNumber      Opcode = 79
+	OpString      Opcode = 80
+	OpVariable    Opcode = 81
+)
+
+/* How the operands of an operator are written, as in ASL's `op_type.hd` */
+type opKind int
+
+const (
+	opInvalid opKind = iota
+	opUnary
+	opBinary
+	opVarArg
+	opPiecewise
+	opIf
+	opSumList
+	opCountList
+)
+
+type opInfo struct {
+	name string
+	kind opKind
+}
+
+/* The operators AMPL writes after an `o`. Function calls, numbers, strings and variables have their own letters */
+var opTable = map[Opcode]opInfo{
+	OpPlus:        {"+", opBinary},
+	OpMinus:       {"-", opBinary},
+	OpMult:        {"*", opBinary},
+	OpDiv:         {"/", opBinary},
+	OpRem:         {"mod", opBinary},
+	OpPow:         {"^", opBinary},
+	OpLess:        {"less", opBinary},
+	OpMinList:     {"min", opVarArg},
+	OpMaxList:     {"max", opVarArg},
+	OpFloor:       {"floor", opUnary},
+	OpCeil:        {"ceil", opUnary},
+	OpAbs:         {"abs", opUnary},
+	OpUMinus:      {"unary -", opUnary},
+	OpOr:          {"||", opBinary},
+	OpAnd:         {"&&", opBinary},
+	OpLT:          {"<", opBinary},
+	OpLE:          {"<=", opBinary},
+	OpEQ:          {"==", opBinary},
+	OpGE:          {">=", opBinary},
+	OpGT:          {">", opBinary},
+	OpNE:          {"!=", opBinary},
+	OpNot:         {"!", opUnary},
+	OpIf:          {"if", opIf},
+	OpTanh:        {"tanh", opUnary},
+	OpTan:         {"tan", opUnary},
+	OpSqrt:        {"sqrt", opUnary},
+	OpSinh:        {"sinh", opUnary},
+	OpSin:         {"sin", opUnary},
+	OpLog10:       {"log10", opUnary},
+	OpLog:         {"log", opUnary},
+	OpExp:         {"exp", opUnary},
+	OpCosh:        {"cosh", opUnary},
+	OpCos:         {"cos", opUnary},
+	OpAtanh:       {"atanh", opUnary},
+	OpAtan2:       {"atan2", opBinary},
+	OpAtan:        {"atan", opUnary},
+	OpAsinh:       {"asinh", opUnary},
+	OpAsin:        {"asin", opUnary},
+	OpAcosh:       {"acosh", opUnary},
+	OpAcos:        {"acos", opUnary},
+	OpSumList:     {"sum", opSumList},
+	OpIntDiv:      {"div", opBinary},
+	OpPrecision:   {"precision", opBinary},
+	OpRound:       {"round", opBinary},
+	OpTrunc:       {"trunc", opBinary},
+	OpCount:       {"count", opCountList},
+	OpNumberOf:    {"numberof", opCountList},
+	OpNumberOfSym: {"numberof", opCountList},
+	OpAtLeast:     {"atleast", opBinary},
+	OpAtMost:      {"atmost", opBinary},
+	OpPLTerm:      {"piecewise-linear term", opPiecewise},
+	OpIfSym:       {"if", opIf},
+	OpExactly:     {"exactly", opBinary},
+	OpNotAtLeast:  {"!atleast", opBinary},
+	OpNotAtMost:   {"!atmost", opBinary},
+	OpNotExactly:  {"!exactly", opBinary},
+	OpAndList:     {"forall", opSumList},
+	OpOrList:      {"exists", opSumList},
+	OpImpElse:     {"==>", opIf},
+	OpIff:         {"<==>", opBinary},
+	OpAllDiff:     {"alldiff", opCountList},
+}
+
+func (op Opcode) String() string {
+	switch op {
+	case OpFuncall:
+		return "function call"
+	case OpNumber:
+		return "number"
+	case OpString:
+		return "string"
+	case OpVariable:
+		return "variable"
+	}
+	if info, ok := opTable[op]; ok {
+		return info.name
+	}
+	return "unknown"
+}
+
+/* A node of an expression graph. Subexpressions AMPL wrote once, the defined variables, are shared between
+   the expressions that use them through `OpVariable` nodes */
+type Expr struct {
+	Op Opcode
+
+	/* The operands. An if-then-else has the condition, the value if it's true and the value if it's false.
+	   A piecewise-linear term has a single operand, the argument of the function */
+	Args []*Expr
+
+	/* The value of a number */
+	Value float64
+
+	/* The index of a variable, or of a function for a function call. Indices from the number of variables
+	   up refer to defined variables, see `DefinedVariables` */
+	Index int
+
+	/* The value of a string argument to a function */
+	String string
+
+	/* The slopes and breakpoints of a piecewise-linear term, which has one more slope than breakpoints.
+	   Slopes[i] applies between Breakpoints[i-1] and Breakpoints[i] */
+	Slopes      []float64
+	Breakpoints []float64
+}
+
+/* A defined variable, AMPL's name for a common subexpression. Its value is the sum of its linear terms and its
+   expression */
+type DefinedVariable struct {
+	/* The index expressions use to refer to the defined variable, which comes after the variables */
+	Index int
+
+	Linear []Term
+	Expr   *Expr
+}
+
+/* A coefficient of a variable or defined variable, by index */
+type Term struct {
+	Index       int
+	Coefficient float64
+}
+
+/* A function imported by the model. Function calls refer to it by its position in `Functions` */
+type Function struct {
+	Name string
+
+	/* Whether the function accepts string arguments */
+	Symbolic bool
+
+	/* The number of arguments, or -(n+1) for a function taking at least n arguments */
+	NumArgs int
+}
+
+/* Get the degree of an expression, following ASL's classification: 0 for constants, 1 for linear, 2 for
+   quadratic and 3 for anything else */
+func (p *Problem) degree(e *Expr) int {
+	switch e.Op {
+	case OpNumber:
+		return 0
+	case OpVariable:
+		if e.Index < len(p.variables) {
+			return 1
+		}
+		return p.definedDegree(e.Index - len(p.variables))
+	case OpUMinus:
+		return p.degree(e.Args[0])
+	case OpPlus, OpMinus:
+		return intMax(p.degree(e.Args[0]), p.degree(e.Args[1]))
+	case OpSumList:
+		d := 0
+		for _, arg := range e.Args {
+			d = intMax(d, p.degree(arg))
+		}
+		return d
+	case OpDiv:
+		if p.degree(e.Args[1]) > 0 {
+			return 3
+		}
+		return p.degree(e.Args[0])
+	case OpMult:
+		d := p.degree(e.Args[0]) + p.degree(e.Args[1])
+		if d > 3 {
+			return 3
+		}
+		return d
+	case OpPow:
+		/* Only squares count as quadratic, other powers of variables are nonlinear */
+		base, exponent := e.Args[0], e.Args[1]
+		if exponent.Op == OpNumber {
+			if d := p.degree(base); exponent.Value == 2 && d < 2 {
+				return 2 * d
+			}
+			return 3
+		}
+		if base.Op == OpNumber && p.degree(exponent) == 0 {
+			return 0
+		}
+		return 3
+	case OpFuncall:
+		for _, arg := range e.Args {
+			if arg.Op != OpString && p.degree(arg) > 0 {
+				return 3
+			}
+		}
+		return 0
+	}
+	return 3
+}
+
+/* Defined variables count as at least linear, as in ASL */
+func (p *Problem) definedDegree(i int) int {
+	if p.definedDegrees[i] < 0 {
+		p.definedDegrees[i] = intMax(1, p.degree(p.defined[i].Expr))
+		for _, term := range p.defined[i].Linear {
+			if term.Index >= len(p.variables) {
+				p.definedDegrees[i] = intMax(p.definedDegrees[i], p.definedDegree(term.Index-len(p.variables)))
+			}
+		}
+	}
+	return p.definedDegrees[i]
+}
+
+/* Return the larger integer */
+func intMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,290 @@
+package nl
+
+/* A package for reading AMPL `.nl` files in pure Go. Unlike the model package it doesn't need cgo or AMPL's
+   solver library, so it cross-compiles and links statically. It reads the text and binary formats into the same
+   Variable, Constraint and Objective types as the model package, along with the expression graphs of the
+   nonlinear parts. The constraints and objectives can be evaluated, along with their gradients and Hessians,
+   using automatic differentiation instead of AMPL's solver library.
+
+   A Problem isn't changed after it's read, so it can be used from several goroutines. Infinite bounds are
+   kept as math.Inf rather than clamped to Plinfy as in the model package. */
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/alanctgardner/ampl-go/types"
+)
+
+/* The types shared with the model package, see the types package */
+type VariableType = types.VariableType
+
+const (
+	VariableReal    = types.VariableReal
+	VariableInteger = types.VariableInteger
+	VariableBinary  = types.VariableBinary
+	VariableArc     = types.VariableArc
+)
+
+type Variable = types.Variable
+
+type ConstraintSense = types.ConstraintSense
+
+const (
+	ConstraintGreaterThan     = types.ConstraintGreaterThan
+	ConstraintLessThan        = types.ConstraintLessThan
+	ConstraintEqualTo         = types.ConstraintEqualTo
+	ConstraintRange           = types.ConstraintRange
+	ConstraintNonBinding      = types.ConstraintNonBinding
+	ConstraintComplementarity = types.ConstraintComplementarity
+)
+
+type Constraint struct {
+	Name      string
+	Sense     ConstraintSense
+	Shape     Shape
+	Min       float64
+	Max       float64
+	Variables []Variable
+	Index     int
+	p         *Problem
+}
+
+/* Get the linear terms of the constraint. Variables that only appear nonlinearly have a coefficient of 0 */
+func (c Constraint) LinearTerms() []LinearTerm {
+	return c.p.linearTerms(c.p.conLinear[c.Index])
+}
+
+/* Get the nonlinear part of the constraint, which is added to the linear terms */
+func (c Constraint) Expression() *Expr {
+	return c.p.conExprs[c.Index]
+}
+
+/* Get the variable a complementarity constraint complements. Returns false if the constraint isn't a
+   complementarity constraint */
+func (c Constraint) ComplementedVariable() (Variable, bool) {
+	if c.Sense != ConstraintComplementarity {
+		return Variable{}, false
+	}
+	return c.p.variables[c.p.cvar[c.Index]], true
+}
+
+type ObjectiveSense = types.ObjectiveSense
+
+const (
+	ObjectiveMin = types.ObjectiveMin
+	ObjectiveMax = types.ObjectiveMax
+)
+
+/* Represents a single objective in the problem */
+type Objective struct {
+	Name      string
+	Sense     ObjectiveSense
+	Shape     Shape
+	Variables []Variable
+	Index     int
+	p         *Problem
+}
+
+/* Get the linear terms of the objective. Variables that only appear nonlinearly have a coefficient of 0 */
+func (o Objective) LinearTerms() []LinearTerm {
+	return o.p.linearTerms(o.p.objLinear[o.Index])
+}
+
+/* Get the nonlinear part of the objective, which is added to the linear terms */
+func (o Objective) Expression() *Expr {
+	return o.p.objExprs[o.Index]
+}
+
+/* Get the constant term of the objective, the sum of the numbers added at the top of its expression */
+func (o Objective) Constant() float64 {
+	e := o.p.objExprs[o.Index]
+	switch e.Op {
+	case OpNumber:
+		return e.Value
+	case OpPlus, OpSumList:
+		constant := 0.0
+		for _, arg := range e.Args {
+			if arg.Op == OpNumber {
+				constant += arg.Value
+			}
+		}
+		return constant
+	}
+	return 0
+}
+
+type Shape = types.Shape
+
+const (
+	Constant  = types.Constant
+	Linear    = types.Linear
+	Quadratic = types.Quadratic
+	NonLinear = types.NonLinear
+)
+
+/* A variable and its coefficient in the linear part of a constraint or objective */
+type LinearTerm = types.LinearTerm
+
+/* A logical constraint, such as an indicator constraint, which has to be true */
+type LogicalConstraint struct {
+	Name  string
+	Index int
+	Expr  *Expr
+}
+
+/* What a suffix is attached to. The values match the kinds in `.nl` files */
+type SuffixKind = types.SuffixKind
+
+const (
+	SuffixVariable   = types.SuffixVariable
+	SuffixConstraint = types.SuffixConstraint
+	SuffixObjective  = types.SuffixObjective
+	SuffixProblem    = types.SuffixProblem
+)
+
+// Flags stored with the kind of a suffix
+const (
+	suffixKindMask = 3
+	suffixKindReal = 4
+)
+
+/* A suffix holds extra values for the variables, constraints, objectives or the problem itself,
+   like the basis status in `sstatus`. Values that aren't set are 0 */
+type Suffix struct {
+	Name string
+	Kind SuffixKind
+
+	/* Whether the values are real numbers rather than integers */
+	Real bool
+
+	/* The nonzero values, indexed by variable, constraint or objective. Problem suffixes use index 0 */
+	Values map[int]float64
+}
+
+/* Get the value of the suffix for the given index */
+func (s *Suffix) Value(index int) float64 {
+	return s.Values[index]
+}
+
+/* A problem read from a `.nl` file */
+type Problem struct {
+	Name string
+
+	/* The counts AMPL wrote at the top of the file */
+	Header Header
+
+	variables   []Variable
+	constraints []Constraint
+	objectives  []Objective
+	logical     []LogicalConstraint
+	defined     []DefinedVariable
+	functions   []Function
+	suffixes    []*Suffix
+
+	conExprs  []*Expr
+	objExprs  []*Expr
+	conLinear [][]Term
+	objLinear [][]Term
+
+	/* The variable each complementarity constraint complements, or -1 */
+	cvar []int
+
+	x0, pi0         []float64
+	haveX0, havePi0 []bool
+
+	/* The degree of each defined variable, or -1 before it's computed */
+	definedDegrees []int
+}
+
+/* Get the list of Variables in this problem */
+func (p *Problem) Variables() []Variable {
+	return append([]Variable(nil), p.variables...)
+}
+
+/* Get the list of Constraints in this problem */
+func (p *Problem) Constraints() []Constraint {
+	return append([]Constraint(nil), p.constraints...)
+}
+
+/* Get the list of Objectives in this problem */
+func (p *Problem) Objectives() []Objective {
+	return append([]Objective(nil), p.objectives...)
+}
+
+/* Get the logical constraints, which AMPL writes separately from the algebraic constraints */
+func (p *Problem) LogicalConstraints() []LogicalConstraint {
+	return append([]LogicalConstraint(nil), p.logical...)
+}
+
+/* Get the number of logical constraints */
+func (p *Problem) NumLogicalConstraints() int {
+	return len(p.logical)
+}
+
+/* Get the defined variables. Expressions refer to defined variable i as variable `len(Variables()) + i` */
+func (p *Problem) DefinedVariables() []DefinedVariable {
+	return append([]DefinedVariable(nil), p.defined...)
+}
+
+/* Get the functions the model imports. Function calls refer to them by index */
+func (p *Problem) Functions() []Function {
+	return append([]Function(nil), p.functions...)
+}
+
+/* Get the index of the variable each constraint complements, or -1 for constraints that aren't complementarity
+   constraints */
+func (p *Problem) ComplementedVariables() []int {
+	return append([]int(nil), p.cvar...)
+}
+
+/* Get the starting point AMPL wrote to the `.nl` file, along with a mask that is true for the variables the user
+   gave an initial value. The other variables are 0 */
+func (p *Problem) InitialPrimal() ([]float64, []bool) {
+	return append([]float64(nil), p.x0...), append([]bool(nil), p.haveX0...)
+}
+
+/* Get the initial dual values AMPL wrote to the `.nl` file, along with a mask that is true for the constraints the
+   user gave an initial dual value. The other constraints are 0 */
+func (p *Problem) InitialDual() ([]float64, []bool) {
+	return append([]float64(nil), p.pi0...), append([]bool(nil), p.havePi0...)
+}
+
+/* Get the suffixes AMPL wrote to the `.nl` file */
+func (p *Problem) Suffixes() []*Suffix {
+	return append([]*Suffix(nil), p.suffixes...)
+}
+
+/* Get the values AMPL sent for a suffix. Returns an error if the file doesn't have the suffix */
+func (p *Problem) Suffix(name string, kind SuffixKind) (*Suffix, error) {
+	for _, suf := range p.suffixes {
+		if suf.Name == name && suf.Kind == kind {
+			return suf, nil
+		}
+	}
+	return nil, fmt.Errorf("Error: %s suffix %s is not in the file", kind, name)
+}
+
+func (p *Problem) linearTerms(terms []Term) []LinearTerm {
+	linear := make([]LinearTerm, len(terms))
+	for i, term := range terms {
+		linear[i] = LinearTerm{Variable: p.variables[term.Index], Coefficient: term.Coefficient}
+	}
+	return linear
+}
+
+/* Get the sense of a constraint from its bounds */
+func constraintSense(min, max float64) ConstraintSense {
+	upperIsInf := math.IsInf(max, 1)
+	lowerIsInf := math.IsInf(min, 0)
+	if upperIsInf && !lowerIsInf {
+		return ConstraintGreaterThan
+	} else if !upperIsInf && lowerIsInf {
+		return ConstraintLessThan
+	} else if min == max {
+		return ConstraintEqualTo
+	} else if !upperIsInf && !lowerIsInf {
+		return ConstraintRange
+	}
+	return ConstraintNonBinding
+}
@@ -0,0 +1,918 @@
+package nl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/* The counts at the top of a `.nl` file, which say how many of each kind of variable, constraint and objective
+   the problem has. Variables are ordered by these counts, see `Variables` */
+type Header struct {
+	/* Whether the body of the file is binary rather than text */
+	Binary bool
+
+	/* The options AMPL passes to the solver, and the bound tolerance if the second option is 3 */
+	Options        []int
+	BoundTolerance float64
+
+	NumVariables          int
+	NumConstraints        int
+	NumObjectives         int
+	NumRanges             int
+	NumEqualities         int
+	NumLogicalConstraints int
+
+	NumNonLinearConstraints int
+	NumNonLinearObjectives  int
+
+	/* Complementarity constraints: linear, nonlinear, double inequalities and those with nonzero lower bounds */
+	NumLinearComplementarity    int
+	NumNonLinearComplementarity int
+	NumDoubleInequalities       int
+	NumNonZeroLowerBounds       int
+
+	NumNonLinearNetworkConstraints int
+	NumLinearNetworkConstraints    int
+
+	/* Variables appearing nonlinearly in constraints, objectives, and both */
+	NumNonLinearConstraintVariables int
+	NumNonLinearObjectiveVariables  int
+	NumNonLinearBothVariables       int
+
+	NumLinearNetworkVariables int
+	NumFunctions              int
+	ArithmeticKind            int
+	Flags                     int
+
+	NumBinaryVariables  int
+	NumIntegerVariables int
+
+	/* Integer variables appearing nonlinearly in both, constraints only and objectives only */
+	NumNonLinearBothIntegerVariables       int
+	NumNonLinearConstraintIntegerVariables int
+	NumNonLinearObjectiveIntegerVariables  int
+
+	NumJacobianNonZeros int
+	NumGradientNonZeros int
+
+	MaxConstraintNameLength int
+	MaxVariableNameLength   int
+
+	/* Defined variables used in both, in constraints, in objectives, in one constraint and in one objective */
+	NumDefinedVariablesBoth          int
+	NumDefinedVariablesConstraints   int
+	NumDefinedVariablesObjectives    int
+	NumDefinedVariablesOneConstraint int
+	NumDefinedVariablesOneObjective  int
+}
+
+/* The total number of defined variables */
+func (h *Header) NumDefinedVariables() int {
+	return h.NumDefinedVariablesBoth + h.NumDefinedVariablesConstraints + h.NumDefinedVariablesObjectives +
+		h.NumDefinedVariablesOneConstraint + h.NumDefinedVariablesOneObjective
+}
+
+/* The number of header lines before the body */
+const headerLines = 10
+
+/* Returned when a `.nl` file can't be read */
+type ReadError struct {
+	/* The path of the file being read, or "" for a reader */
+	Path string
+
+	/* The line of a text file where the error was found, or 0 */
+	Line int
+
+	/* The byte offset in a binary file where the error was found, or 0 */
+	Offset int64
+
+	Message string
+}
+
+func (e *ReadError) Error() string {
+	str := "Error reading"
+	if e.Path != "" {
+		str += fmt.Sprintf(" %q", e.Path)
+	}
+	if e.Line > 0 {
+		str += fmt.Sprintf(" at line %d", e.Line)
+	} else if e.Offset > 0 {
+		str += fmt.Sprintf(" at byte %d", e.Offset)
+	}
+	return str + ": " + e.Message
+}
+
+/* Load a problem from a `.nl` file. The names of the variables, constraints and objectives are read from the
+   `.col` and `.row` files next to it, if AMPL wrote them (`option auxfiles rc`); otherwise they get generic
+   names like `_svar[1]`, as in the model package. Returns a *ReadError if the file is malformed */
+func LoadProblem(path string) (*Problem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &ReadError{Path: path, Message: err.Error()}
+	}
+	defer f.Close()
+	stub := strings.TrimSuffix(path, ".nl")
+	colNames, err := readNames(stub + ".col")
+	if err != nil {
+		return nil, &ReadError{Path: stub + ".col", Message: err.Error()}
+	}
+	rowNames, err := readNames(stub + ".row")
+	if err != nil {
+		return nil, &ReadError{Path: stub + ".row", Message: err.Error()}
+	}
+	p, err := read(f, path, colNames, rowNames)
+	if err != nil {
+		return nil, err
+	}
+	p.Name = path
+	return p, nil
+}
+
+/* Read a problem in `.nl` format. The variables, constraints and objectives get generic names. Returns a
+   *ReadError if the input is malformed */
+func Read(r io.Reader) (*Problem, error) {
+	return read(r, "", nil, nil)
+}
+
+/* Read the names in a `.col` or `.row` file, one per line. Returns nil if there is no such file */
+func readNames(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	names := make([]string, 0)
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		names = append(names, strings.TrimRight(lines.Text(), "\r"))
+	}
+	return names, lines.Err()
+}
+
+type reader struct {
+	s    scanner
+	path string
+	h    *Header
+	p    *Problem
+
+	/* Bounds on the variables and constraints, as lower and upper pairs */
+	varBounds []float64
+	conBounds []float64
+
+	/* Whether the `k` segment has been read, after which the Jacobian segments don't have column offsets */
+	columnsRead bool
+}
+
+func read(in io.Reader, path string, colNames, rowNames []string) (*Problem, error) {
+	r := &reader{path: path, p: &Problem{}}
+	b := bufio.NewReader(in)
+	if err := r.readHeader(b); err != nil {
+		return nil, err
+	}
+	if err := r.readSegments(); err != nil {
+		line, offset := r.s.position()
+		return nil, &ReadError{Path: path, Line: line, Offset: offset, Message: strings.TrimPrefix(err.Error(), "Error: ")}
+	}
+	r.finish(colNames, rowNames)
+	return r.p, nil
+}
+
+/* Read the ten lines of counts at the top of the file. These are text in both formats */
+func (r *reader) readHeader(b *bufio.Reader) error {
+	h := &r.p.Header
+	r.h = h
+	lines := make([][]string, headerLines)
+	offset := int64(0)
+	for i := range lines {
+		line, err := b.ReadString('\n')
+		offset += int64(len(line))
+		if err != nil {
+			return &ReadError{Path: r.path, Line: i + 1, Message: "Unexpected end of file in the header"}
+		}
+		if j := strings.IndexByte(line, '#'); j >= 0 {
+			line = line[:j]
+		}
+		lines[i] = strings.Fields(line)
+	}
+	badLine := func(i int) error {
+		return &ReadError{Path: r.path, Line: i + 1, Message: "Invalid header line"}
+	}
+
+	/* The first line has the format, then the number of options and the options */
+	if len(lines[0]) == 0 || len(lines[0][0]) < 2 {
+		return badLine(0)
+	}
+	switch lines[0][0][0] {
+	case 'g', 'G':
+	case 'b', 'B':
+		h.Binary = true
+	default:
+		return &ReadError{Path: r.path, Line: 1, Message: fmt.Sprintf("Unsupported format %q", lines[0][0][:1])}
+	}
+	fields := append([]string{lines[0][0][1:]}, lines[0][1:]...)
+	numOptions, err := strconv.Atoi(fields[0])
+	if err != nil || numOptions < 0 || numOptions > 9 || len(fields) < numOptions+1 {
+		return badLine(0)
+	}
+	h.Options = make([]int, numOptions)
+	for i := range h.Options {
+		if h.Options[i], err = strconv.Atoi(fields[i+1]); err != nil {
+			return badLine(0)
+		}
+	}
+	if numOptions >= 2 && h.Options[1] == 3 {
+		if len(fields) < numOptions+2 {
+			return badLine(0)
+		}
+		if h.BoundTolerance, err = strconv.ParseFloat(fields[numOptions+1], 64); err != nil {
+			return badLine(0)
+		}
+	}
+
+	/* The other lines are integers, some of which are optional */
+	counts := []struct {
+		min  int
+		vals []*int
+	}{
+		{3, []*int{&h.NumVariables, &h.NumConstraints, &h.NumObjectives, &h.NumRanges, &h.NumEqualities, &h.NumLogicalConstraints}},
+		{2, []*int{&h.NumNonLinearConstraints, &h.NumNonLinearObjectives, &h.NumLinearComplementarity,
+			&h.NumNonLinearComplementarity, &h.NumDoubleInequalities, &h.NumNonZeroLowerBounds}},
+		{2, []*int{&h.NumNonLinearNetworkConstraints, &h.NumLinearNetworkConstraints}},
+		{2, []*int{&h.NumNonLinearConstraintVariables, &h.NumNonLinearObjectiveVariables, &h.NumNonLinearBothVariables}},
+		{2, []*int{&h.NumLinearNetworkVariables, &h.NumFunctions, &h.ArithmeticKind, &h.Flags}},
+		{5, []*int{&h.NumBinaryVariables, &h.NumIntegerVariables, &h.NumNonLinearBothIntegerVariables,
+			&h.NumNonLinearConstraintIntegerVariables, &h.NumNonLinearObjectiveIntegerVariables}},
+		{2, []*int{&h.NumJacobianNonZeros, &h.NumGradientNonZeros}},
+		{2, []*int{&h.MaxConstraintNameLength, &h.MaxVariableNameLength}},
+		{5, []*int{&h.NumDefinedVariablesBoth, &h.NumDefinedVariablesConstraints, &h.NumDefinedVariablesObjectives,
+			&h.NumDefinedVariablesOneConstraint, &h.NumDefinedVariablesOneObjective}},
+	}
+	for i, line := range lines[1:] {
+		c := counts[i]
+		if len(line) < c.min {
+			return badLine(i + 1)
+		}
+		for j := 0; j < len(line) && j < len(c.vals); j++ {
+			if *c.vals[j], err = strconv.Atoi(line[j]); err != nil || *c.vals[j] < 0 {
+				return badLine(i + 1)
+			}
+		}
+	}
+	if h.NumVariables <= 0 {
+		return &ReadError{Path: r.path, Line: 2, Message: "The problem has no variables"}
+	}
+
+	if !h.Binary {
+		r.s = newTextScanner(b, headerLines+1)
+		return nil
+	}
+	/* The arithmetic kind is 1 for little-endian and 2 for big-endian doubles, or 0 if it isn't known */
+	switch h.ArithmeticKind {
+	case 0, 1:
+		r.s = newBinaryScanner(b, offset, binary.LittleEndian)
+	case 2:
+		r.s = newBinaryScanner(b, offset, binary.BigEndian)
+	default:
+		return &ReadError{Path: r.path, Line: 6, Message: fmt.Sprintf("Unsupported arithmetic kind %d", h.ArithmeticKind)}
+	}
+	return nil
+}
+
+/* Read the segments after the header, which can come in any order */
+func (r *reader) readSegments() error {
+	h := r.h
+	p := r.p
+	numVars := h.NumVariables
+	numCons := h.NumConstraints
+	p.defined = make([]DefinedVariable, h.NumDefinedVariables())
+	p.definedDegrees = make([]int, len(p.defined))
+	for i := range p.defined {
+		p.defined[i] = DefinedVariable{Index: numVars + i, Expr: &Expr{Op: OpNumber}}
+		p.definedDegrees[i] = -1
+	}
+	p.functions = make([]Function, h.NumFunctions)
+	p.conExprs = make([]*Expr, numCons)
+	p.conLinear = make([][]Term, numCons)
+	for i := range p.conExprs {
+		p.conExprs[i] = &Expr{Op: OpNumber}
+	}
+	p.objExprs = make([]*Expr, h.NumObjectives)
+	p.objLinear = make([][]Term, h.NumObjectives)
+	for i := range p.objExprs {
+		p.objExprs[i] = &Expr{Op: OpNumber}
+	}
+	p.logical = make([]LogicalConstraint, h.NumLogicalConstraints)
+	for i := range p.logical {
+		p.logical[i] = LogicalConstraint{Index: i, Expr: &Expr{Op: OpNumber}}
+	}
+	p.objectives = make([]Objective, h.NumObjectives)
+	p.cvar = make([]int, numCons)
+	for i := range p.cvar {
+		p.cvar[i] = -1
+	}
+	p.x0, p.haveX0 = make([]float64, numVars), make([]bool, numVars)
+	p.pi0, p.havePi0 = make([]float64, numCons), make([]bool, numCons)
+
+	/* Variables default to being free, constraints to being unbounded */
+	r.varBounds = make([]float64, 2*numVars)
+	r.conBounds = make([]float64, 2*numCons)
+	for i := 0; i < len(r.varBounds); i += 2 {
+		r.varBounds[i], r.varBounds[i+1] = math.Inf(-1), math.Inf(1)
+	}
+	for i := 0; i < len(r.conBounds); i += 2 {
+		r.conBounds[i], r.conBounds[i+1] = math.Inf(-1), math.Inf(1)
+	}
+
+	for {
+		key, err := r.s.key()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch key {
+		case 'C':
+			k, err := r.index("constraint", numCons)
+			if err != nil {
+				return err
+			}
+			if p.conExprs[k], err = r.expr(); err != nil {
+				return err
+			}
+		case 'L':
+			k, err := r.index("logical constraint", h.NumLogicalConstraints)
+			if err != nil {
+				return err
+			}
+			if p.logical[k].Expr, err = r.expr(); err != nil {
+				return err
+			}
+		case 'O':
+			k, err := r.index("objective", h.NumObjectives)
+			if err != nil {
+				return err
+			}
+			sense, err := r.s.integer()
+			if err != nil {
+				return err
+			}
+			if sense != int(ObjectiveMin) && sense != int(ObjectiveMax) {
+				return fmt.Errorf("Error: Invalid objective sense %d", sense)
+			}
+			p.objectives[k].Sense = ObjectiveSense(sense)
+			if p.objExprs[k], err = r.expr(); err != nil {
+				return err
+			}
+		case 'V':
+			if err := r.readDefinedVariable(); err != nil {
+				return err
+			}
+		case 'F':
+			if err := r.readFunction(); err != nil {
+				return err
+			}
+		case 'J':
+			k, err := r.index("constraint", numCons)
+			if err != nil {
+				return err
+			}
+			if p.conLinear[k], err = r.readLinear(!r.columnsRead); err != nil {
+				return err
+			}
+		case 'G':
+			k, err := r.index("objective", h.NumObjectives)
+			if err != nil {
+				return err
+			}
+			if p.objLinear[k], err = r.readLinear(false); err != nil {
+				return err
+			}
+		case 'b':
+			if err := r.readBounds(r.varBounds, false); err != nil {
+				return err
+			}
+		case 'r':
+			if err := r.readBounds(r.conBounds, true); err != nil {
+				return err
+			}
+		case 'k', 'K':
+			if err := r.readColumns(); err != nil {
+				return err
+			}
+		case 'x':
+			if err := r.readInitial(p.x0, p.haveX0); err != nil {
+				return err
+			}
+		case 'd':
+			if err := r.readInitial(p.pi0, p.havePi0); err != nil {
+				return err
+			}
+		case 'S':
+			if err := r.readSuffix(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Error: Unknown segment %q", key)
+		}
+	}
+}
+
+/* Read an index, which has to be less than n */
+func (r *reader) index(what string, n int) (int, error) {
+	k, err := r.s.integer()
+	if err != nil {
+		return 0, err
+	}
+	if k < 0 || k >= n {
+		return 0, fmt.Errorf("Error: Invalid %s index %d", what, k)
+	}
+	return k, nil
+}
+
+/* Read a count, which has to be at least min and at most max */
+func (r *reader) count(what string, min, max int) (int, error) {
+	n, err := r.s.integer()
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("Error: Invalid number of %s %d", what, n)
+	}
+	return n, nil
+}
+
+/* Read an expression, which is written in prefix order */
+func (r *reader) expr() (*Expr, error) {
+	key, err := r.s.key()
+	if err == io.EOF {
+		return nil, fmt.Errorf("Error: Unexpected end of file, expected an expression")
+	} else if err != nil {
+		return nil, err
+	}
+	switch key {
+	case 'n':
+		x, err := r.s.float()
+		return &Expr{Op: OpNumber, Value: x}, err
+	case 's':
+		i, err := r.s.short()
+		return &Expr{Op: OpNumber, Value: float64(i)}, err
+	case 'l':
+		i, err := r.s.long()
+		return &Expr{Op: OpNumber, Value: float64(i)}, err
+	case 'v':
+		k, err := r.index("variable", r.h.NumVariables+len(r.p.defined))
+		return &Expr{Op: OpVariable, Index: k}, err
+	case 'h':
+		str, err := r.s.literal()
+		return &Expr{Op: OpString, String: str}, err
+	case 'f':
+		k, err := r.index("function", r.h.NumFunctions)
+		if err != nil {
+			return nil, err
+		}
+		n, err := r.count("function arguments", 0, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		return r.operands(&Expr{Op: OpFuncall, Index: k}, n)
+	case 'o':
+	default:
+		return nil, fmt.Errorf("Error: Unknown expression node %q", key)
+	}
+
+	code, err := r.s.opcode()
+	if err != nil {
+		return nil, err
+	}
+	op := Opcode(code)
+	info, ok := opTable[op]
+	if !ok {
+		return nil, fmt.Errorf("Error: Unknown operator %d", code)
+	}
+	e := &Expr{Op: op}
+	switch info.kind {
+	case opUnary:
+		return r.operands(e, 1)
+	case opBinary:
+		return r.operands(e, 2)
+	case opIf:
+		return r.operands(e, 3)
+	case opVarArg, opCountList:
+		n, err := r.count("operands", 1, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		return r.operands(e, n)
+	case opSumList:
+		n, err := r.count("operands", 3, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		return r.operands(e, n)
+	}
+
+	/* A piecewise-linear term has the number of slopes, then the slopes alternating with the breakpoints */
+	n, err := r.count("slopes", 2, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	e.Slopes = make([]float64, n)
+	e.Breakpoints = make([]float64, n-1)
+	for i := 0; i < 2*n-1; i++ {
+		x, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+		if i%2 == 0 {
+			e.Slopes[i/2] = x
+		} else {
+			e.Breakpoints[i/2] = x
+		}
+	}
+	return r.operands(e, 1)
+}
+
+/* Read a number node in a piecewise-linear term */
+func (r *reader) number() (float64, error) {
+	e, err := r.expr()
+	if err != nil {
+		return 0, err
+	}
+	if e.Op != OpNumber {
+		return 0, fmt.Errorf("Error: Expected a number in a piecewise-linear term")
+	}
+	return e.Value, nil
+}
+
+func (r *reader) operands(e *Expr, n int) (*Expr, error) {
+	e.Args = make([]*Expr, n)
+	for i := range e.Args {
+		arg, err := r.expr()
+		if err != nil {
+			return nil, err
+		}
+		e.Args[i] = arg
+	}
+	return e, nil
+}
+
+/* A defined variable has its index, the number of linear terms, where it's used, the linear terms and its
+   expression */
+func (r *reader) readDefinedVariable() error {
+	numVars := r.h.NumVariables
+	k, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	if k < numVars || k >= numVars+len(r.p.defined) {
+		return fmt.Errorf("Error: Invalid defined variable index %d", k)
+	}
+	n, err := r.count("linear terms", 0, numVars+len(r.p.defined))
+	if err != nil {
+		return err
+	}
+	if _, err := r.s.integer(); err != nil {
+		return err
+	}
+	d := &r.p.defined[k-numVars]
+	d.Linear = make([]Term, n)
+	for i := range d.Linear {
+		if d.Linear[i].Index, err = r.index("variable", k); err != nil {
+			return err
+		}
+		if d.Linear[i].Coefficient, err = r.s.float(); err != nil {
+			return err
+		}
+	}
+	d.Expr, err = r.expr()
+	return err
+}
+
+func (r *reader) readFunction() error {
+	k, err := r.index("function", r.h.NumFunctions)
+	if err != nil {
+		return err
+	}
+	symbolic, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	numArgs, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	name, err := r.s.word()
+	if err != nil {
+		return err
+	}
+	r.p.functions[k] = Function{Name: name, Symbolic: symbolic&1 != 0, NumArgs: numArgs}
+	return nil
+}
+
+/* Read the linear terms of a constraint or objective, sorted by variable. Without a `k` segment the Jacobian
+   terms also have their offsets in the column, which aren't needed */
+func (r *reader) readLinear(offsets bool) ([]Term, error) {
+	n, err := r.count("linear terms", 1, r.h.NumVariables)
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]Term, n)
+	for i := range terms {
+		if terms[i].Index, err = r.index("variable", r.h.NumVariables); err != nil {
+			return nil, err
+		}
+		if offsets {
+			if _, err := r.s.integer(); err != nil {
+				return nil, err
+			}
+		}
+		if terms[i].Coefficient, err = r.s.float(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool { return terms[i].Index < terms[j].Index })
+	return terms, nil
+}
+
+/* Read the bounds on each variable or constraint. Each starts with its type: 0 for a range, 1 for an upper bound,
+   2 for a lower bound, 3 for none, 4 for equality and, for constraints, 5 for a complementarity constraint */
+func (r *reader) readBounds(bounds []float64, constraints bool) error {
+	for i := 0; i < len(bounds); i += 2 {
+		key, err := r.s.key()
+		if err == io.EOF {
+			return fmt.Errorf("Error: Unexpected end of file, expected a bound")
+		} else if err != nil {
+			return err
+		}
+		lower, upper := &bounds[i], &bounds[i+1]
+		switch key {
+		case '0':
+			if *lower, err = r.s.float(); err == nil {
+				*upper, err = r.s.float()
+			}
+		case '1':
+			*upper, err = r.s.float()
+		case '2':
+			*lower, err = r.s.float()
+		case '3':
+		case '4':
+			*lower, err = r.s.float()
+			*upper = *lower
+		case '5':
+			if !constraints {
+				return fmt.Errorf("Error: Variables can't have complementarity bounds")
+			}
+			err = r.readComplementarity(i/2, lower, upper)
+		default:
+			return fmt.Errorf("Error: Invalid bound type %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* A complementarity constraint has flags for which of its bounds are infinite and the variable it complements,
+   counted from 1 */
+func (r *reader) readComplementarity(k int, lower, upper *float64) error {
+	flags, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	v, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	if v <= 0 || v > r.h.NumVariables {
+		return fmt.Errorf("Error: Invalid complemented variable %d", v)
+	}
+	r.p.cvar[k] = v - 1
+	*lower, *upper = 0, 0
+	if flags&2 != 0 {
+		*lower = math.Inf(-1)
+	}
+	if flags&1 != 0 {
+		*upper = math.Inf(1)
+	}
+	return nil
+}
+
+/* The `k` segment has the cumulative column lengths of the Jacobian, which aren't needed */
+func (r *reader) readColumns() error {
+	if _, err := r.count("columns", r.h.NumVariables-1, r.h.NumVariables-1); err != nil {
+		return err
+	}
+	for i := 0; i < r.h.NumVariables-1; i++ {
+		if _, err := r.s.integer(); err != nil {
+			return err
+		}
+	}
+	r.columnsRead = true
+	return nil
+}
+
+func (r *reader) readInitial(values []float64, given []bool) error {
+	n, err := r.count("initial values", 0, len(values))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		j, err := r.index("initial value", len(values))
+		if err != nil {
+			return err
+		}
+		if values[j], err = r.s.float(); err != nil {
+			return err
+		}
+		given[j] = true
+	}
+	return nil
+}
+
+/* A suffix has its kind, the number of values and its name, then index and value pairs */
+func (r *reader) readSuffix() error {
+	kind, err := r.s.integer()
+	if err != nil {
+		return err
+	}
+	if kind < 0 || kind > 7 {
+		return fmt.Errorf("Error: Invalid suffix kind %d", kind)
+	}
+	h := r.h
+	size := []int{h.NumVariables, h.NumConstraints + h.NumLogicalConstraints, h.NumObjectives, 1}[kind&suffixKindMask]
+	n, err := r.count("suffix values", 1, size)
+	if err != nil {
+		return err
+	}
+	name, err := r.s.word()
+	if err != nil {
+		return err
+	}
+	suf := &Suffix{
+		Name:   name,
+		Kind:   SuffixKind(kind & suffixKindMask),
+		Real:   kind&suffixKindReal != 0,
+		Values: make(map[int]float64),
+	}
+	for i := 0; i < n; i++ {
+		j, err := r.index(name+" suffix", size)
+		if err != nil {
+			return err
+		}
+		var val float64
+		if suf.Real {
+			val, err = r.s.float()
+		} else {
+			var k int
+			k, err = r.s.integer()
+			val = float64(k)
+		}
+		if err != nil {
+			return err
+		}
+		if val != 0 {
+			suf.Values[j] = val
+		}
+	}
+	r.p.suffixes = append(r.p.suffixes, suf)
+	return nil
+}
+
+/* Fill in the variables, constraints and objectives once the whole file has been read */
+func (r *reader) finish(colNames, rowNames []string) {
+	h := r.h
+	p := r.p
+	name := func(names []string, i int, format string, j int) string {
+		if i < len(names) && names[i] != "" {
+			return names[i]
+		}
+		return fmt.Sprintf(format, j+1)
+	}
+
+	types := variableTypes(h, r.varBounds)
+	p.variables = make([]Variable, h.NumVariables)
+	for j := range p.variables {
+		p.variables[j] = Variable{
+			Name:       name(colNames, j, "_svar[%d]", j),
+			Type:       types[j],
+			LowerBound: r.varBounds[2*j],
+			UpperBound: r.varBounds[2*j+1],
+			Index:      j,
+		}
+	}
+
+	r.adjustComplementarity()
+	p.constraints = make([]Constraint, h.NumConstraints)
+	for i := range p.constraints {
+		c := &p.constraints[i]
+		c.Name = name(rowNames, i, "_scon[%d]", i)
+		c.Min, c.Max = r.conBounds[2*i], r.conBounds[2*i+1]
+		c.Sense = constraintSense(c.Min, c.Max)
+		if p.cvar[i] >= 0 {
+			c.Sense = ConstraintComplementarity
+		}
+		c.Shape = p.shape(p.conLinear[i], p.conExprs[i])
+		c.Variables = make([]Variable, len(p.conLinear[i]))
+		for j, term := range p.conLinear[i] {
+			c.Variables[j] = p.variables[term.Index]
+		}
+		c.Index = i
+		c.p = p
+	}
+
+	for i := range p.logical {
+		p.logical[i].Name = name(rowNames, h.NumConstraints+i, "_slogcon[%d]", i)
+	}
+
+	for i := range p.objectives {
+		o := &p.objectives[i]
+		o.Name = name(rowNames, h.NumConstraints+h.NumLogicalConstraints+i, "_sobj[%d]", i)
+		o.Shape = p.shape(p.objLinear[i], p.objExprs[i])
+		o.Variables = make([]Variable, len(p.objLinear[i]))
+		for j, term := range p.objLinear[i] {
+			o.Variables[j] = p.variables[term.Index]
+		}
+		o.Index = i
+		o.p = p
+	}
+}
+
+/* AMPL writes the constant of a linear complementarity constraint as its body, since the bounds are fixed by the
+   flags. Move it into the finite bounds, as ASL does */
+func (r *reader) adjustComplementarity() {
+	p := r.p
+	for i := r.h.NumNonLinearConstraints; i < r.h.NumConstraints; i++ {
+		e := p.conExprs[i]
+		if p.cvar[i] < 0 || e.Op != OpNumber || e.Value == 0 {
+			continue
+		}
+		constant := e.Value
+		lower, upper := &r.conBounds[2*i], &r.conBounds[2*i+1]
+		if !math.IsInf(*lower, -1) {
+			*lower -= e.Value
+			constant = 0
+		}
+		if !math.IsInf(*upper, 1) {
+			*upper -= e.Value
+			constant = 0
+		}
+		p.conExprs[i] = &Expr{Op: OpNumber, Value: constant}
+	}
+}
+
+/* Get the shape of a constraint or objective. Any linear terms make it at least linear, even when their
+   coefficients are 0 */
+func (p *Problem) shape(linear []Term, e *Expr) Shape {
+	d := p.degree(e)
+	if len(linear) > 0 && d == 0 {
+		d = 1
+	}
+	return Shape(d)
+}
+
+/* Get the type of each variable from the counts in the header. AMPL orders the variables as: nonlinear in
+   both constraints and objectives, nonlinear in constraints, nonlinear in objectives, each with the integer
+   variables last; then linear arcs, other linear variables, binary variables and integer variables */
+func variableTypes(h *Header, bounds []float64) []VariableType {
+	types := make([]VariableType, 0, h.NumVariables)
+	add := func(n int, t VariableType) {
+		for i := 0; i < n && len(types) < h.NumVariables; i++ {
+			types = append(types, t)
+		}
+	}
+	// Nonlinear integer variables may in fact be binary - check if the bounds are 0 and 1
+	addInteger := func(n int) {
+		for i := 0; i < n && len(types) < h.NumVariables; i++ {
+			j := len(types)
+			if bounds[2*j] == 0 && bounds[2*j+1] == 1 {
+				types = append(types, VariableBinary)
+			} else {
+				types = append(types, VariableInteger)
+			}
+		}
+	}
+	numNonLinear := intMax(h.NumNonLinearConstraintVariables, h.NumNonLinearObjectiveVariables)
+	add(h.NumNonLinearBothVariables-h.NumNonLinearBothIntegerVariables, VariableReal)
+	addInteger(h.NumNonLinearBothIntegerVariables)
+	add(h.NumNonLinearConstraintVariables-(h.NumNonLinearBothVariables+h.NumNonLinearConstraintIntegerVariables), VariableReal)
+	addInteger(h.NumNonLinearConstraintIntegerVariables)
+	add(h.NumNonLinearObjectiveVariables-(h.NumNonLinearConstraintVariables+h.NumNonLinearObjectiveIntegerVariables), VariableReal)
+	addInteger(h.NumNonLinearObjectiveIntegerVariables)
+	add(h.NumLinearNetworkVariables, VariableArc)
+	add(h.NumVariables-(numNonLinear+h.NumBinaryVariables+h.NumIntegerVariables+h.NumLinearNetworkVariables), VariableReal)
+	add(h.NumBinaryVariables, VariableBinary)
+	add(h.NumIntegerVariables, VariableInteger)
+	/* Variables the counts don't cover are real */
+	add(h.NumVariables-len(types), VariableReal)
+	return types
+}
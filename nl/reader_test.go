@@ -0,0 +1,223 @@
+package nl
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"strings"
+	"testing"
+)
+
+/* The test problems live with the model package, which reads them with AMPL's reader */
+const (
+	testDietFile      = "../model/diet1.nl"
+	testNonLinearFile = "../model/hs1.nl"
+	testDefVarFile    = "../model/defvar.nl"
+	testBinaryFile    = "../model/defvar_binary.nl"
+)
+
+/* The header of a problem with two hand-written segments: a piecewise-linear term and a call to an imported
+   function with a string argument */
+const testFunctionHeader = `g3 1 1 0	# problem funcs
+ 1 1 0 0 0	# vars, constraints, objectives, ranges, eqns
+ 1 0	# nonlinear constraints, objectives
+ 0 0	# network constraints: nonlinear, linear
+ 1 0 0	# nonlinear vars in constraints, objectives, both
+ 0 1 0 1	# linear network variables; functions; arith, flags
+ 0 0 0 0 0	# discrete variables: binary, integer, nonlinear (b,c,o)
+ 1 0	# nonzeros in Jacobian, gradients
+ 0 0	# max name lengths: constraints, variables
+ 0 0 0 0 0	# common exprs: b,c,o,c1,o1
+`
+
+/* Read the counts at the top of the file */
+func TestReadHeader(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testNonLinearFile)
+	assert.Nil(err, "No error")
+	h := p.Header
+	assert.False(h.Binary, "Text format")
+	assert.Equal(h.Options, []int{1, 1, 0}, "Options")
+	assert.Equal(h.NumVariables, 2, "Variables")
+	assert.Equal(h.NumConstraints, 2, "Constraints")
+	assert.Equal(h.NumObjectives, 1, "Objectives")
+	assert.Equal(h.NumNonLinearBothVariables, 2, "Nonlinear variables")
+	assert.Equal(h.NumJacobianNonZeros, 4, "Jacobian nonzeros")
+	assert.Equal(h.ArithmeticKind, 0, "Arithmetic kind")
+	assert.Equal(h.Flags, 1, "Flags")
+}
+
+/* Read the variables, constraints and objectives of a linear problem */
+func TestReadDiet(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testDietFile)
+	assert.Nil(err, "No error")
+	vars := p.Variables()
+	assert.Equal(len(vars), 9, "Number of variables")
+	assert.Equal(vars[0], Variable{Name: "_svar[1]", Type: VariableInteger, LowerBound: 0, UpperBound: 11, Index: 0}, "variable 1")
+	assert.Equal(vars[8], Variable{Name: "_svar[9]", Type: VariableInteger, LowerBound: 0, UpperBound: 18, Index: 8}, "variable 9")
+
+	cons := p.Constraints()
+	assert.Equal(len(cons), 7, "Number of constraints")
+	assert.Equal(cons[0].Sense, ConstraintGreaterThan, "Constraint 1")
+	assert.Equal(cons[0].Min, 2000.0, "Constraint 1 lower bound")
+	assert.True(math.IsInf(cons[0].Max, 1), "Constraint 1 upper bound")
+	assert.Equal(cons[1].Sense, ConstraintRange, "Constraint 2")
+	assert.Equal(cons[1].Shape, Linear, "Constraint 2")
+	assert.Equal(len(cons[3].Variables), 8, "Constraint 4 variables")
+	assert.Equal(cons[4].LinearTerms()[3], LinearTerm{Variable: vars[4], Coefficient: 15}, "Constraint 5 coefficient")
+
+	objs := p.Objectives()
+	assert.Equal(len(objs), 8, "Number of objectives")
+	assert.Equal(objs[0].Sense, ObjectiveMin, "Objective 1")
+	assert.Equal(objs[0].Shape, Linear, "Objective 1")
+}
+
+/* Read the expression graphs of a nonlinear problem */
+func TestReadExpressions(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testNonLinearFile)
+	assert.Nil(err, "No error")
+	square := func(v int) *Expr {
+		return &Expr{Op: OpPow, Args: []*Expr{{Op: OpVariable, Index: v}, {Op: OpNumber, Value: 2}}}
+	}
+	cons := p.Constraints()
+	assert.Equal(cons[0].Expression(), &Expr{Op: OpPlus, Args: []*Expr{square(0), square(1)}}, "x^2 + y^2")
+	assert.Equal(cons[0].Shape, Quadratic, "Quadratic constraint")
+	assert.Equal(cons[1].Expression(), &Expr{Op: OpNumber}, "Linear constraint")
+	assert.Equal(cons[1].Shape, Linear, "Linear constraint")
+	assert.Equal(cons[1].LinearTerms(), []LinearTerm{{Variable: p.Variables()[0], Coefficient: 1}, {Variable: p.Variables()[1], Coefficient: 2}}, "Linear terms")
+
+	obj := p.Objectives()[0]
+	assert.Equal(obj.Expression().Op, OpSumList, "Sum")
+	assert.Equal(len(obj.Expression().Args), 3, "Sum")
+	assert.Equal(obj.Expression().Args[2], &Expr{Op: OpExp, Args: []*Expr{{Op: OpVariable, Index: 1}}}, "exp(y)")
+	assert.Equal(obj.Shape, NonLinear, "Nonlinear objective")
+
+	x0, given := p.InitialPrimal()
+	assert.Equal(x0, []float64{1, 2}, "Initial values")
+	assert.Equal(given, []bool{true, true}, "Initial values given")
+}
+
+/* Read a defined variable and the expressions that use it */
+func TestReadDefinedVariables(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem(testDefVarFile)
+	assert.Nil(err, "No error")
+	x := &Expr{Op: OpVariable, Index: 0}
+	defined := &Expr{Op: OpVariable, Index: 1}
+	assert.Equal(p.DefinedVariables(), []DefinedVariable{
+		{Index: 1, Linear: []Term{{0, 2}}, Expr: &Expr{Op: OpSin, Args: []*Expr{x}}},
+	}, "t = 2x + sin(x)")
+	assert.Equal(p.Constraints()[0].Expression(), &Expr{Op: OpIf, Args: []*Expr{
+		{Op: OpLT, Args: []*Expr{x, {Op: OpNumber, Value: 1}}},
+		defined,
+		{Op: OpNumber, Value: 3},
+	}}, "if x < 1 then t else 3")
+	assert.Equal(p.Objectives()[0].Expression(), &Expr{Op: OpMult, Args: []*Expr{defined, defined}}, "t * t")
+	assert.Equal(p.Objectives()[0].Shape, NonLinear, "Nonlinear objective")
+}
+
+/* A binary file reads the same as the text file it was written from */
+func TestReadBinary(t *testing.T) {
+	assert := assert.New(t)
+	text, err := LoadProblem(testDefVarFile)
+	assert.Nil(err, "No error")
+	p, err := LoadProblem(testBinaryFile)
+	assert.Nil(err, "No error")
+	assert.True(p.Header.Binary, "Binary format")
+	assert.Equal(p.Variables(), text.Variables(), "Variables")
+	assert.Equal(p.DefinedVariables(), text.DefinedVariables(), "Defined variables")
+	assert.Equal(p.Constraints()[0].Expression(), text.Constraints()[0].Expression(), "Constraint")
+	assert.Equal(p.Objectives()[0].Expression(), text.Objectives()[0].Expression(), "Objective")
+	assert.Equal(p.Constraints()[0].Max, 5.0, "Constraint bound")
+}
+
+/* Names come from the .col and .row files */
+func TestReadNames(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem("../model/miqp.nl")
+	assert.Nil(err, "No error")
+	vars := p.Variables()
+	assert.Equal([]string{vars[0].Name, vars[1].Name, vars[2].Name}, []string{"x", "z", "y"}, "Variable names")
+	assert.Equal(p.Constraints()[1].Name, "c2", "Constraint name")
+	assert.Equal(p.Objectives()[0].Name, "profit", "Objective name")
+	assert.Equal(p.Objectives()[0].Sense, ObjectiveMax, "Objective sense")
+}
+
+/* Every suffix in the file is read */
+func TestReadSuffixes(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem("../model/suffix.nl")
+	assert.Nil(err, "No error")
+	assert.Equal(len(p.Suffixes()), 5, "Number of suffixes")
+	sstatus, err := p.Suffix("sstatus", SuffixVariable)
+	assert.Nil(err, "No error")
+	assert.Equal(sstatus, &Suffix{Name: "sstatus", Kind: SuffixVariable, Values: map[int]float64{0: 1, 1: 3}}, "sstatus")
+	scale, err := p.Suffix("scale", SuffixConstraint)
+	assert.Nil(err, "No error")
+	assert.True(scale.Real, "Real suffix")
+	assert.Equal(scale.Value(1), 2.5, "scale")
+	_, err = p.Suffix("sstatus", SuffixConstraint)
+	assert.NotNil(err, "No such suffix")
+}
+
+/* Complementarity and logical constraints */
+func TestReadComplementarity(t *testing.T) {
+	assert := assert.New(t)
+	p, err := LoadProblem("../model/mpec.nl")
+	assert.Nil(err, "No error")
+	cons := p.Constraints()
+	assert.Equal(cons[0].Sense, ConstraintComplementarity, "Complementarity constraint")
+	v, ok := cons[0].ComplementedVariable()
+	assert.True(ok, "Complemented variable")
+	assert.Equal(v.Index, 1, "Complemented variable")
+	// The constant of the body moves into the bounds
+	assert.Equal(cons[0].Min, -1.0, "Lower bound")
+	assert.Equal(p.ComplementedVariables(), []int{1, -1}, "Complemented variables")
+
+	p, err = LoadProblem("../model/indicator.nl")
+	assert.Nil(err, "No error")
+	logical := p.LogicalConstraints()
+	assert.Equal(len(logical), 2, "Logical constraints")
+	assert.Equal(logical[0].Name, "_slogcon[1]", "Logical constraint name")
+	assert.Equal(logical[0].Expr.Op, OpImpElse, "Implication")
+	assert.Equal(p.Variables()[2].Type, VariableBinary, "Binary variable")
+}
+
+/* Piecewise-linear terms, imported functions and string arguments */
+func TestReadFunctions(t *testing.T) {
+	assert := assert.New(t)
+	body := "F0 1 -1 myfunc\nC0\no0\no64\n3\nn-1\nn0\nn1\nn2\nn3\nv0\nf0 2\nv0\nh6:a\nb:cd\nr\n1 4\nb\n3\nk0\nJ0 1\n0 0\n"
+	p, err := Read(strings.NewReader(testFunctionHeader + body))
+	assert.Nil(err, "No error")
+	assert.Equal(p.Functions(), []Function{{Name: "myfunc", Symbolic: true, NumArgs: -1}}, "Functions")
+	x := &Expr{Op: OpVariable, Index: 0}
+	assert.Equal(p.Constraints()[0].Expression(), &Expr{Op: OpPlus, Args: []*Expr{
+		{Op: OpPLTerm, Slopes: []float64{-1, 1, 3}, Breakpoints: []float64{0, 2}, Args: []*Expr{x}},
+		{Op: OpFuncall, Index: 0, Args: []*Expr{x, {Op: OpString, String: "a\nb:cd"}}},
+	}}, "Expression")
+	assert.Equal(p.Constraints()[0].Shape, NonLinear, "Nonlinear constraint")
+	assert.Equal(p.Constraints()[0].Name, "_scon[1]", "Generic name")
+}
+
+/* Malformed files return a ReadError at the line of the problem */
+func TestReadErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := LoadProblem("missing.nl")
+	assert.IsType(err, &ReadError{}, "Missing file")
+
+	_, err = Read(strings.NewReader("x3 1 1 0\n"))
+	assert.IsType(err, &ReadError{}, "Bad format")
+
+	_, err = Read(strings.NewReader(testFunctionHeader + "C0\nn0\nQ0\n"))
+	assert.Equal(err, &ReadError{Line: 13, Message: `Unknown segment 'Q'`}, "Unknown segment")
+
+	_, err = Read(strings.NewReader(testFunctionHeader + "C0\no99\nv0\n"))
+	assert.Equal(err, &ReadError{Line: 12, Message: "Unknown operator 99"}, "Unknown operator")
+
+	_, err = Read(strings.NewReader(testFunctionHeader + "C0\no0\nv0\n"))
+	assert.Equal(err, &ReadError{Line: 14, Message: "Unexpected end of file, expected an expression"}, "Truncated file")
+
+	_, err = Read(strings.NewReader(testFunctionHeader + "C1\nn0\n"))
+	assert.Equal(err.Error(), "Error reading at line 11: Invalid constraint index 1", "Bad index")
+}
@@ -0,0 +1,264 @@
+package nl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+/* Reads the body of a `.nl` file, which follows the header in text or binary. Each segment, expression node and
+   bound starts with a letter or digit, followed by numbers whose encoding depends on the format */
+type scanner interface {
+	/* Read the character that starts the next segment, expression node or bound. Returns io.EOF at the end of
+	   the file */
+	key() (byte, error)
+
+	integer() (int, error)
+
+	/* Read an integer written as a short, after an `s` */
+	short() (int, error)
+
+	/* Read an integer written as a long, after an `l` */
+	long() (int, error)
+
+	float() (float64, error)
+
+	/* Read the opcode after an `o` */
+	opcode() (int, error)
+
+	/* Read a name, like the name of a suffix or function */
+	word() (string, error)
+
+	/* Read a string literal after an `h` */
+	literal() (string, error)
+
+	/* Describe the position of the last value read, for errors */
+	position() (line int, offset int64)
+}
+
+/* Reads the text format, where each value is written as a word. Comments run from `#` to the end of the line */
+type textScanner struct {
+	r    *bufio.Reader
+	line int
+}
+
+func newTextScanner(r *bufio.Reader, line int) *textScanner {
+	return &textScanner{r: r, line: line}
+}
+
+/* Skip whitespace and comments. Returns io.EOF if there is nothing left */
+func (s *textScanner) skip() error {
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '\n':
+			s.line++
+		case ' ', '\t', '\r':
+		case '#':
+			if _, err := s.r.ReadString('\n'); err != nil {
+				return err
+			}
+			s.line++
+		default:
+			return s.r.UnreadByte()
+		}
+	}
+}
+
+func (s *textScanner) key() (byte, error) {
+	if err := s.skip(); err != nil {
+		return 0, err
+	}
+	return s.r.ReadByte()
+}
+
+/* Read the next word, which ends at whitespace or a comment */
+func (s *textScanner) next(what string) (string, error) {
+	if err := s.skip(); err != nil {
+		return "", s.unexpectedEOF(err, what)
+	}
+	word := make([]byte, 0, 16)
+	for {
+		c, err := s.r.ReadByte()
+		if err == io.EOF {
+			return string(word), nil
+		} else if err != nil {
+			return "", err
+		}
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '#' {
+			return string(word), s.r.UnreadByte()
+		}
+		word = append(word, c)
+	}
+}
+
+func (s *textScanner) unexpectedEOF(err error, what string) error {
+	if err == io.EOF {
+		return fmt.Errorf("Error: Unexpected end of file, expected %s", what)
+	}
+	return err
+}
+
+func (s *textScanner) integer() (int, error) {
+	word, err := s.next("an integer")
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(word)
+	if err != nil {
+		return 0, fmt.Errorf("Error: Expected an integer, got %q", word)
+	}
+	return i, nil
+}
+
+func (s *textScanner) short() (int, error) {
+	return s.integer()
+}
+
+func (s *textScanner) long() (int, error) {
+	return s.integer()
+}
+
+func (s *textScanner) opcode() (int, error) {
+	return s.integer()
+}
+
+func (s *textScanner) float() (float64, error) {
+	word, err := s.next("a number")
+	if err != nil {
+		return 0, err
+	}
+	x, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error: Expected a number, got %q", word)
+	}
+	return x, nil
+}
+
+func (s *textScanner) word() (string, error) {
+	return s.next("a name")
+}
+
+/* String literals are written as `length:characters`, and may contain newlines */
+func (s *textScanner) literal() (string, error) {
+	length, err := s.r.ReadString(':')
+	if err != nil {
+		return "", s.unexpectedEOF(err, "a string")
+	}
+	n, err := strconv.Atoi(length[:len(length)-1])
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("Error: Invalid string length %q", length[:len(length)-1])
+	}
+	str := make([]byte, n)
+	if _, err := io.ReadFull(s.r, str); err != nil {
+		return "", fmt.Errorf("Error: Unexpected end of file in a string")
+	}
+	for _, c := range str {
+		if c == '\n' {
+			s.line++
+		}
+	}
+	return string(str), nil
+}
+
+func (s *textScanner) position() (int, int64) {
+	return s.line, 0
+}
+
+/* Reads the binary format, where integers are 4 bytes, shorts 2, longs 8 and numbers are 8 byte doubles.
+   Names and strings are written as their length followed by the characters */
+type binaryScanner struct {
+	r      *bufio.Reader
+	order  binary.ByteOrder
+	offset int64
+	buf    [8]byte
+}
+
+func newBinaryScanner(r *bufio.Reader, offset int64, order binary.ByteOrder) *binaryScanner {
+	return &binaryScanner{r: r, order: order, offset: offset}
+}
+
+func (s *binaryScanner) read(n int, what string) ([]byte, error) {
+	read, err := io.ReadFull(s.r, s.buf[:n])
+	s.offset += int64(read)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Unexpected end of file, expected %s", what)
+	}
+	return s.buf[:n], nil
+}
+
+func (s *binaryScanner) key() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	s.offset++
+	return c, nil
+}
+
+func (s *binaryScanner) integer() (int, error) {
+	b, err := s.read(4, "an integer")
+	if err != nil {
+		return 0, err
+	}
+	return int(int32(s.order.Uint32(b))), nil
+}
+
+func (s *binaryScanner) short() (int, error) {
+	b, err := s.read(2, "a short")
+	if err != nil {
+		return 0, err
+	}
+	return int(int16(s.order.Uint16(b))), nil
+}
+
+func (s *binaryScanner) long() (int, error) {
+	b, err := s.read(8, "a long")
+	if err != nil {
+		return 0, err
+	}
+	return int(int64(s.order.Uint64(b))), nil
+}
+
+func (s *binaryScanner) opcode() (int, error) {
+	return s.integer()
+}
+
+func (s *binaryScanner) float() (float64, error) {
+	b, err := s.read(8, "a number")
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(s.order.Uint64(b)), nil
+}
+
+func (s *binaryScanner) word() (string, error) {
+	return s.literal()
+}
+
+func (s *binaryScanner) literal() (string, error) {
+	n, err := s.integer()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", fmt.Errorf("Error: Invalid string length %d", n)
+	}
+	str := make([]byte, n)
+	read, err := io.ReadFull(s.r, str)
+	s.offset += int64(read)
+	if err != nil {
+		return "", fmt.Errorf("Error: Unexpected end of file in a string")
+	}
+	return string(str), nil
+}
+
+func (s *binaryScanner) position() (int, int64) {
+	return 0, s.offset
+}
@@ -0,0 +1,333 @@
+package solver
+
+/* A package for writing AMPL solvers in Go. It handles the command line and options the way AMPL's
+   getstub does, loads the problem, and writes the `.sol` file for AMPL to read back:
+
+       mysolver stub [-AMPL] [keyword=value ...]
+       mysolver -=     list the keywords
+       mysolver -v     print the version
+
+   Keywords are read from the `<name>_options` environment variable first, then the command line. */
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alanctgardner/ampl-go/model"
+)
+
+/* The type of value an option takes */
+type OptionKind int
+
+const (
+	OptionInt OptionKind = iota
+	OptionReal
+	OptionString
+	OptionFlag
+)
+
+func (k OptionKind) String() string {
+	switch k {
+	case OptionInt:
+		return "int"
+	case OptionReal:
+		return "real"
+	case OptionString:
+		return "string"
+	case OptionFlag:
+		return "flag"
+	}
+	return "unknown"
+}
+
+/* A keyword the solver accepts. Its value is stored in the variable it was registered with */
+type Option struct {
+	Name        string
+	Description string
+	Kind        OptionKind
+
+	intVal    *int
+	realVal   *float64
+	stringVal *string
+	flagVal   *bool
+}
+
+/* Parse `value` and store it in the option's variable */
+func (o *Option) set(value string) error {
+	switch o.Kind {
+	case OptionInt:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("Error: Expected an integer value for %s, got %q", o.Name, value)
+		}
+		*o.intVal = v
+	case OptionReal:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("Error: Expected a real value for %s, got %q", o.Name, value)
+		}
+		*o.realVal = v
+	case OptionString:
+		*o.stringVal = value
+	case OptionFlag:
+		*o.flagVal = true
+	}
+	return nil
+}
+
+/* The solution returned by a solve function */
+type Result struct {
+	/* The message printed for the user and returned to AMPL */
+	Message string
+
+	/* The values of the variables and the dual values of the constraints. Either can be nil */
+	Primal []float64
+	Dual   []float64
+
+	/* The solve_result_num: 0-99 solved, 100-199 solved?, 200-299 infeasible, 300-399 unbounded,
+	   400-499 limit and 500-599 failure */
+	SolveResultNum int
+
+	/* Suffixes to return to AMPL */
+	Suffixes []*model.Suffix
+}
+
+/* A function that solves the problem. An error, or a nil result, is reported as a failure to AMPL */
+type SolveFunc func(p *model.Problem) (*Result, error)
+
+/* A solver driver with its registry of options */
+type Solver struct {
+	/* The name of the solver, used for the `<name>_options` environment variable */
+	Name string
+
+	/* The version printed by `-v` and the `version` keyword */
+	Version string
+
+	/* Suffixes to read from the `.nl` file */
+	Suffixes []model.SuffixDecl
+
+	/* Where messages are printed, os.Stdout by default */
+	Stdout io.Writer
+
+	/* Whether to write the `.sol` file (1), print the primal (2) or dual (4) values, and suppress the
+	   message (8). The `.sol` file is always written when invoked with -AMPL */
+	WantSol int
+
+	options map[string]*Option
+	version bool
+}
+
+/* Create a solver with the built-in `wantsol` and `version` keywords */
+func New(name, version string) *Solver {
+	s := &Solver{Name: name, Version: version, Stdout: os.Stdout, options: make(map[string]*Option)}
+	s.IntOption("wantsol", "solution report without -AMPL: sum of 1 = write .sol file, 2 = print primal variable values, 4 = print dual variable values, 8 = do not print solution message", &s.WantSol)
+	s.FlagOption("version", "report version", &s.version)
+	return s
+}
+
+func (s *Solver) addOption(o *Option) {
+	if _, ok := s.options[o.Name]; ok {
+		panic(fmt.Sprintf("Error: Option %s is already registered", o.Name))
+	}
+	s.options[o.Name] = o
+}
+
+/* Register a keyword taking an integer value */
+func (s *Solver) IntOption(name, description string, value *int) {
+	s.addOption(&Option{Name: name, Description: description, Kind: OptionInt, intVal: value})
+}
+
+/* Register a keyword taking a real value */
+func (s *Solver) RealOption(name, description string, value *float64) {
+	s.addOption(&Option{Name: name, Description: description, Kind: OptionReal, realVal: value})
+}
+
+/* Register a keyword taking a string value */
+func (s *Solver) StringOption(name, description string, value *string) {
+	s.addOption(&Option{Name: name, Description: description, Kind: OptionString, stringVal: value})
+}
+
+/* Register a keyword without a value, which sets `value` to true when it's given */
+func (s *Solver) FlagOption(name, description string, value *bool) {
+	s.addOption(&Option{Name: name, Description: description, Kind: OptionFlag, flagVal: value})
+}
+
+/* Get the options sorted by name */
+func (s *Solver) Options() []*Option {
+	opts := make([]*Option, 0, len(s.options))
+	for _, o := range s.options {
+		opts = append(opts, o)
+	}
+	sort.Slice(opts, func(i, j int) bool { return opts[i].Name < opts[j].Name })
+	return opts
+}
+
+/* Parse keywords like `name=value`, `name value` or `flag`, setting the registered options */
+func (s *Solver) ParseOptions(words []string) error {
+	for i := 0; i < len(words); i++ {
+		name, value := words[i], ""
+		hasValue := false
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		o, ok := s.options[name]
+		if !ok {
+			return fmt.Errorf("Error: Unknown keyword %q", name)
+		}
+		if o.Kind != OptionFlag && !hasValue {
+			if i+1 >= len(words) {
+				return fmt.Errorf("Error: Missing value for %s", name)
+			}
+			i++
+			value = words[i]
+		} else if o.Kind == OptionFlag && hasValue {
+			return fmt.Errorf("Error: Keyword %s doesn't take a value", name)
+		}
+		if err := o.set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Split an options string into words on whitespace, keeping quoted strings together */
+func splitOptions(str string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, c := range str {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(c)
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Error: Unterminated quote in options %q", str)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+/* Print the keywords and their descriptions, like `-=` in other AMPL drivers */
+func (s *Solver) printOptions() {
+	width := 0
+	for _, o := range s.options {
+		if len(o.Name) > width {
+			width = len(o.Name)
+		}
+	}
+	for _, o := range s.Options() {
+		fmt.Fprintf(s.Stdout, "%-*s  %s (%s)\n", width, o.Name, o.Description, o.Kind)
+	}
+}
+
+func (s *Solver) printVersion() {
+	fmt.Fprintf(s.Stdout, "%s %s\n", s.Name, s.Version)
+}
+
+/* Run the solver with the command line arguments, without the program name. Loads the problem from the stub,
+   calls `solve` and writes the `.sol` file. Returns an error if the arguments are bad or the problem can't be
+   read or written; errors from `solve` are returned to AMPL as a failure instead */
+func (s *Solver) Run(args []string, solve SolveFunc) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Error: Usage: %s stub [-AMPL] [keyword=value ...]", s.Name)
+	}
+	switch args[0] {
+	case "-=":
+		s.printOptions()
+		return nil
+	case "-v":
+		s.printVersion()
+		return nil
+	}
+	stub := args[0]
+	if strings.HasPrefix(stub, "-") {
+		return fmt.Errorf("Error: Unknown flag %q", stub)
+	}
+	args = args[1:]
+	amplFlag := false
+	if len(args) > 0 && args[0] == "-AMPL" {
+		amplFlag = true
+		args = args[1:]
+	}
+
+	envWords, err := splitOptions(os.Getenv(s.Name + "_options"))
+	if err != nil {
+		return err
+	}
+	if err := s.ParseOptions(append(envWords, args...)); err != nil {
+		return err
+	}
+	if s.version {
+		s.printVersion()
+	}
+
+	if !strings.HasSuffix(stub, ".nl") {
+		stub += ".nl"
+	}
+	p, err := model.LoadProblemWithSuffixes(stub, s.Suffixes)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	result, err := solve(p)
+	if err != nil {
+		result = &Result{Message: err.Error(), SolveResultNum: 500}
+	} else if result == nil {
+		result = &Result{Message: "no result", SolveResultNum: 500}
+	}
+	message := s.Name + ": " + result.Message
+	if s.WantSol&8 == 0 {
+		fmt.Fprintln(s.Stdout, message)
+	}
+	if s.WantSol&2 != 0 {
+		for _, v := range p.Variables() {
+			if v.Index < len(result.Primal) {
+				fmt.Fprintf(s.Stdout, "%s = %v\n", v.Name, result.Primal[v.Index])
+			}
+		}
+	}
+	if s.WantSol&4 != 0 {
+		for _, c := range p.Constraints() {
+			if c.Index < len(result.Dual) {
+				fmt.Fprintf(s.Stdout, "%s dual = %v\n", c.Name, result.Dual[c.Index])
+			}
+		}
+	}
+	if amplFlag || s.WantSol&1 != 0 {
+		p.SetSolveResultNum(result.SolveResultNum)
+		return p.WriteSolution(message, result.Primal, result.Dual, result.Suffixes)
+	}
+	return nil
+}
+
+/* Run the solver with the program's arguments and exit, with status 1 if it fails */
+func (s *Solver) Main(solve SolveFunc) {
+	if err := s.Run(os.Args[1:], solve); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
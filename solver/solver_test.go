@@ -0,0 +1,151 @@
+package solver
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alanctgardner/ampl-go/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* Copy the nonlinear test problem into a temporary directory, returning the stub */
+func tempStub(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ampl-go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile("../model/hs1.nl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := filepath.Join(dir, "hs1")
+	if err := ioutil.WriteFile(stub+".nl", data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return stub, func() { os.RemoveAll(dir) }
+}
+
+/* A solver with one option of each kind */
+func testSolver(out *bytes.Buffer) (*Solver, *int, *float64, *string, *bool) {
+	s := New("gosolver", "1.0")
+	s.Stdout = out
+	var maxIter int
+	var tol float64
+	var method string
+	var verbose bool
+	s.IntOption("maxiter", "maximum iterations", &maxIter)
+	s.RealOption("tol", "tolerance", &tol)
+	s.StringOption("method", "algorithm to use", &method)
+	s.FlagOption("verbose", "print progress", &verbose)
+	return s, &maxIter, &tol, &method, &verbose
+}
+
+/* Solve a problem invoked by AMPL, with options from the environment and the command line */
+func TestRunAMPL(t *testing.T) {
+	assert := assert.New(t)
+	stub, cleanup := tempStub(t)
+	defer cleanup()
+	var out bytes.Buffer
+	s, maxIter, tol, method, verbose := testSolver(&out)
+	os.Setenv("gosolver_options", "maxiter=10 method 'interior point' verbose")
+	defer os.Unsetenv("gosolver_options")
+
+	err := s.Run([]string{stub, "-AMPL", "tol=1e-8", "maxiter", "20"}, func(p *model.Problem) (*Result, error) {
+		return &Result{
+			Message:        fmt.Sprintf("optimal solution, %d variables", len(p.Variables())),
+			Primal:         []float64{-0.5, 0.75},
+			Dual:           []float64{0, 0.5},
+			SolveResultNum: 0,
+			Suffixes:       []*model.Suffix{{Name: "sstatus", Kind: model.SuffixVariable, Values: map[int]float64{0: 1}}},
+		}, nil
+	})
+	assert.Nil(err, "No error")
+	assert.Equal(*maxIter, 20, "Command line overrides environment")
+	assert.Equal(*tol, 1e-8, "Real option")
+	assert.Equal(*method, "interior point", "Quoted string option")
+	assert.True(*verbose, "Flag option")
+	assert.Equal(out.String(), "gosolver: optimal solution, 2 variables\n", "Message")
+
+	p := model.ProblemFromFile(stub + ".nl")
+	defer p.Close()
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.Message, "gosolver: optimal solution, 2 variables", "Message")
+	assert.Equal(sol.Primal, []float64{-0.5, 0.75}, "Primal values")
+	assert.Equal(sol.Dual, []float64{0, 0.5}, "Dual values")
+	assert.Equal(sol.SolveResultNum, 0, "Solve result")
+	assert.Equal(sol.Suffix("sstatus", model.SuffixVariable).Values, map[int]float64{0: 1}, "Suffix")
+}
+
+/* Without -AMPL the .sol file is only written if wantsol asks for it, and errors are reported as failures */
+func TestRunWantSol(t *testing.T) {
+	assert := assert.New(t)
+	stub, cleanup := tempStub(t)
+	defer cleanup()
+	var out bytes.Buffer
+	s, _, _, _, _ := testSolver(&out)
+	failing := func(p *model.Problem) (*Result, error) {
+		return nil, fmt.Errorf("out of luck")
+	}
+
+	assert.Nil(s.Run([]string{stub}, failing), "No error")
+	assert.Equal(out.String(), "gosolver: out of luck\n", "Message")
+	_, err := os.Stat(stub + ".sol")
+	assert.True(os.IsNotExist(err), "No .sol file")
+
+	out.Reset()
+	assert.Nil(s.Run([]string{stub + ".nl", "wantsol=9"}, failing), "No error")
+	assert.Equal(out.String(), "", "No message")
+	p := model.ProblemFromFile(stub + ".nl")
+	defer p.Close()
+	sol, err := p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.SolveResult(), "failure", "Solve result")
+	assert.Nil(sol.Primal, "No primal values")
+
+	out.Reset()
+	noResult := func(p *model.Problem) (*Result, error) {
+		return nil, nil
+	}
+	assert.Nil(s.Run([]string{stub, "wantsol=1"}, noResult), "No error")
+	assert.Equal(out.String(), "gosolver: no result\n", "Message")
+	sol, err = p.ReadSolution("")
+	assert.Nil(err, "No error")
+	assert.Equal(sol.SolveResultNum, 500, "Failure")
+}
+
+/* List the options with -= and the version with -v */
+func TestListOptionsAndVersion(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	s, _, _, _, _ := testSolver(&out)
+	assert.Nil(s.Run([]string{"-="}, nil), "No error")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(len(lines), 6, "One line per option")
+	assert.Equal(lines[0], "maxiter  maximum iterations (int)", "Sorted by name")
+	assert.Equal(lines[2], "tol      tolerance (real)", "Padded names")
+
+	out.Reset()
+	assert.Nil(s.Run([]string{"-v"}, nil), "No error")
+	assert.Equal(out.String(), "gosolver 1.0\n", "Version")
+}
+
+/* Bad keywords and values are errors */
+func TestBadOptions(t *testing.T) {
+	assert := assert.New(t)
+	var out bytes.Buffer
+	s, _, _, _, _ := testSolver(&out)
+	assert.EqualError(s.ParseOptions([]string{"bogus=1"}), `Error: Unknown keyword "bogus"`, "Unknown keyword")
+	assert.EqualError(s.ParseOptions([]string{"maxiter=ten"}), `Error: Expected an integer value for maxiter, got "ten"`, "Bad integer")
+	assert.EqualError(s.ParseOptions([]string{"tol"}), "Error: Missing value for tol", "Missing value")
+	assert.EqualError(s.ParseOptions([]string{"verbose=1"}), "Error: Keyword verbose doesn't take a value", "Flag with value")
+	assert.NotNil(s.Run([]string{}, nil), "No stub")
+	assert.NotNil(s.Run([]string{"missing", "-AMPL"}, nil), "Missing file")
+
+	_, err := splitOptions("method='unterminated")
+	assert.NotNil(err, "Unterminated quote")
+}
@@ -0,0 +1,148 @@
+package types
+
+/* A package holding the types the model and nl packages share: variables, the senses and shapes of constraints
+   and objectives, linear terms and suffix kinds. It doesn't use cgo, so the pure Go reader can use it without
+   AMPL's solver library. The model and nl packages alias these types, so they can be used from either one. */
+
+import (
+	"strconv"
+)
+
+type VariableType int
+
+const (
+	VariableReal VariableType = iota
+	VariableInteger
+	VariableBinary
+	VariableArc
+)
+
+func (t VariableType) String() string {
+	switch t {
+	case VariableReal:
+		return "Real"
+	case VariableInteger:
+		return "Integer"
+	case VariableBinary:
+		return "Binary"
+	case VariableArc:
+		return "Arc"
+	}
+	return "Unknown"
+}
+
+type Variable struct {
+	Name       string
+	Type       VariableType
+	LowerBound float64
+	UpperBound float64
+	Index      int
+}
+
+func (v Variable) String() string {
+	str := "Name: " + v.Name
+	str += " Type: " + v.Type.String()
+	str += " Min: " + strconv.FormatFloat(v.LowerBound, 'E', -1, 64)
+	str += " Max: " + strconv.FormatFloat(v.UpperBound, 'E', -1, 64)
+	return str
+}
+
+type ConstraintSense int
+
+const (
+	ConstraintGreaterThan ConstraintSense = iota
+	ConstraintLessThan
+	ConstraintEqualTo
+	ConstraintRange
+	ConstraintNonBinding
+	ConstraintComplementarity
+)
+
+func (t ConstraintSense) String() string {
+	switch t {
+	case ConstraintGreaterThan:
+		return "Greater"
+	case ConstraintLessThan:
+		return "Less"
+	case ConstraintEqualTo:
+		return "Equals"
+	case ConstraintRange:
+		return "Range"
+	case ConstraintNonBinding:
+		return "Non-binding"
+	case ConstraintComplementarity:
+		return "Complements"
+	}
+	return "Unknown"
+}
+
+type ObjectiveSense int
+
+const (
+	ObjectiveMin ObjectiveSense = 0
+	ObjectiveMax ObjectiveSense = 1
+)
+
+func (s ObjectiveSense) String() string {
+	if s == ObjectiveMin {
+		return "min"
+	} else if s == ObjectiveMax {
+		return "max"
+	} else {
+		return "unknown"
+	}
+}
+
+/* The shape of a constraint or objective. The values match the classes AMPL's solver library finds */
+type Shape int
+
+const (
+	Constant Shape = iota
+	Linear
+	Quadratic
+	NonLinear
+)
+
+func (c Shape) String() string {
+	switch c {
+	case Constant:
+		return "Constant"
+	case Linear:
+		return "Linear"
+	case Quadratic:
+		return "Quadratic"
+	case NonLinear:
+		return "Non-Linear"
+	}
+	return "Unknown"
+}
+
+/* A variable and its coefficient in the linear part of a constraint or objective */
+type LinearTerm struct {
+	Variable    Variable
+	Coefficient float64
+}
+
+/* What a suffix is attached to. The values match the ASL_Sufkind codes and the kinds in `.nl` files */
+type SuffixKind int
+
+const (
+	SuffixVariable SuffixKind = iota
+	SuffixConstraint
+	SuffixObjective
+	SuffixProblem
+)
+
+func (k SuffixKind) String() string {
+	switch k {
+	case SuffixVariable:
+		return "Variable"
+	case SuffixConstraint:
+		return "Constraint"
+	case SuffixObjective:
+		return "Objective"
+	case SuffixProblem:
+		return "Problem"
+	}
+	return "Unknown"
+}